@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// unixScheme prefixes the server's addresses naming a Unix domain socket.
+const unixScheme = "unix://"
+
+type (
+	// ServerEndpoint represents a server's address in the form expected by
+	// [net.Listen].
+	ServerEndpoint struct {
+		// Network specifies the network: "tcp", or "unix" for a Unix domain socket.
+		Network string
+
+		// Address specifies the address: "<host>:port", or the path of the socket
+		// for Unix domain sockets.
+		Address string
+	}
+
+	// listenAddress is a parsed server's address.
+	listenAddress struct {
+		network string
+		address string
+		host    string
+		port    int
+	}
+)
+
+// String returns the server's address a was parsed from, in canonical form.
+func (a listenAddress) String() string {
+	if a.network == "unix" {
+		return unixScheme + a.address
+	}
+	return a.address
+}
+
+// key returns the identity of a, under which equivalent addresses (e.g., ":80"
+// and ":http") compare equal.
+func (a listenAddress) key() string {
+	if a.network == "unix" {
+		return a.String()
+	}
+	return net.JoinHostPort(a.host, strconv.Itoa(a.port))
+}
+
+// lookupAddresses returns the comma-separated server's addresses of v. Every
+// invalid entry is reported as an error, in which case the default is returned,
+// and duplicate entries are dropped with a warning.
+func (l *loader) lookupAddresses(v variable) []listenAddress {
+	fallback := func() []listenAddress {
+		var addrs []listenAddress
+		for _, s := range splitList(v.fallback, ",") {
+			if a, err := parseAddress(s); err == nil {
+				addrs = append(addrs, a)
+			}
+		}
+		return addrs
+	}
+	val, ok := l.find(v)
+	if !ok {
+		return fallback()
+	}
+	entries := splitList(val.raw, ",")
+	if len(entries) == 0 {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: fmt.Errorf("%w: missing address", ErrInvalidAddress), kind: ErrInvalidAddress})
+		return fallback()
+	}
+	var (
+		addrs  []listenAddress
+		seen   = make(map[string]bool)
+		failed bool
+	)
+	for _, e := range entries {
+		shown := value{raw: e, sensitive: val.sensitive}.shown()
+		a, err := parseAddress(e)
+		if err != nil {
+			l.addError(&ParseError{Var: val.name, Value: shown, Err: err, kind: ErrInvalidAddress})
+			failed = true
+			continue
+		}
+		if seen[a.key()] {
+			l.addWarning(&ValidationError{Var: val.name, Value: shown, Reason: "duplicate address", kind: ErrDuplicateAddress})
+			continue
+		}
+		seen[a.key()] = true
+		addrs = append(addrs, a)
+	}
+	if failed {
+		return fallback()
+	}
+	return addrs
+}
+
+// parseAddress parses addr, either "unix://" followed by the path of a Unix
+// domain socket (e.g., "unix:///run/mega.sock", "unix://./mega.sock") or a TCP
+// address in the "<host>:port" form. The host of a TCP address may be empty or
+// an IPv6 literal, and its port either a number within [TCPPortMin, TCPPortMax]
+// or a service name resolved by [net.LookupPort].
+func parseAddress(addr string) (listenAddress, error) {
+	if len(addr) >= len(unixScheme) && strings.EqualFold(addr[:len(unixScheme)], unixScheme) {
+		path := addr[len(unixScheme):]
+		if path == "" {
+			return listenAddress{}, fmt.Errorf("%w: missing socket path", ErrInvalidAddress)
+		}
+		return listenAddress{network: "unix", address: path}, nil
+	}
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return listenAddress{}, fmt.Errorf("%w: %w", ErrInvalidAddress, unwrapAddrError(err))
+	}
+	if p == "" {
+		return listenAddress{}, fmt.Errorf("%w: missing port", ErrInvalidAddress)
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		if port, err = net.LookupPort("tcp", p); err != nil {
+			return listenAddress{}, fmt.Errorf("%w: unknown port %q", ErrInvalidAddress, p)
+		}
+	}
+	if port < TCPPortMin || port > TCPPortMax {
+		return listenAddress{}, fmt.Errorf("%w: port %d out of range [%d, %d]", ErrInvalidAddress, port, TCPPortMin, TCPPortMax)
+	}
+	return listenAddress{network: "tcp", address: addr, host: host, port: port}, nil
+}
+
+// unwrapAddrError returns the bare reason of a [net.AddrError], whose message
+// otherwise repeats the address.
+func unwrapAddrError(err error) error {
+	if e, ok := err.(*net.AddrError); ok {
+		return errors.New(e.Err)
+	}
+	return err
+}
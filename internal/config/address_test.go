@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServerAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		host string
+		port int
+	}{
+		{":8080", "", 8080},
+		{":0", "", 0},
+		{"localhost:65535", "localhost", 65535},
+		{"127.0.0.1:80", "127.0.0.1", 80},
+		{"[::1]:8080", "::1", 8080},
+		{"[fe80::1%eth0]:443", "fe80::1%eth0", 443},
+		{":http", "", 80},
+		{"example.com:https", "example.com", 443},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: tt.addr})))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if c.ServerAddress() != tt.addr || c.ServerHost() != tt.host || c.ServerPort() != tt.port {
+				t.Errorf("got %q, %q and %d, want %q, %q and %d",
+					c.ServerAddress(), c.ServerHost(), c.ServerPort(), tt.addr, tt.host, tt.port)
+			}
+			if c.ServerNetwork() != "tcp" {
+				t.Errorf("ServerNetwork() = %q, want tcp", c.ServerNetwork())
+			}
+		})
+	}
+}
+
+func TestServerAddressInvalid(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"localhost", "missing port in address"},
+		{"host:99999", "port 99999 out of range [0, 65535]"},
+		{"host:-1", "port -1 out of range [0, 65535]"},
+		{"host:", "missing port"},
+		{"host:nosuchservice", `unknown port "nosuchservice"`},
+		{"::1:8080", "too many colons in address"},
+		{"[::1", "missing ']' in address"},
+		{"a b c", "missing port in address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			t.Parallel()
+			_, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: tt.addr})))
+			if !errors.Is(err, ErrInvalidAddress) {
+				t.Fatalf("New() error = %v, want %v", err, ErrInvalidAddress)
+			}
+			if want := `(SERVER_ADDRESS) got="` + tt.addr + `"`; !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not echo the value: want %q", err, want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerAddressDefault(t *testing.T) {
+	c, err := New(WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ServerHost() != "localhost" || c.ServerPort() != 8080 {
+		t.Errorf("got %q and %d, want localhost and 8080", c.ServerHost(), c.ServerPort())
+	}
+}
+
+func TestServerAddressUnix(t *testing.T) {
+	tests := []struct {
+		addr string
+		path string
+	}{
+		{"unix:///var/run/app.sock", "/var/run/app.sock"},
+		{"unix://./app.sock", "./app.sock"},
+		{"UNIX:///run/app.sock", "/run/app.sock"},
+		{"unix:///tmp/app:99999", "/tmp/app:99999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: tt.addr})))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if c.ServerNetwork() != "unix" || c.ServerAddress() != tt.path {
+				t.Errorf("got %q and %q, want unix and %q", c.ServerNetwork(), c.ServerAddress(), tt.path)
+			}
+			if c.ServerHost() != "" || c.ServerPort() != 0 {
+				t.Errorf("got host %q and port %d, want none", c.ServerHost(), c.ServerPort())
+			}
+		})
+	}
+
+	_, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: "unix://"})))
+	if !errors.Is(err, ErrInvalidAddress) || !strings.Contains(err.Error(), "missing socket path") {
+		t.Errorf("New() error = %v, want a missing socket path", err)
+	}
+}
+
+func TestServerUnixSocketMode(t *testing.T) {
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvServerUnixSocketMode: "0600"})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ServerUnixSocketMode() != 0o600 {
+		t.Errorf("ServerUnixSocketMode() = %v, want 0600", c.ServerUnixSocketMode())
+	}
+	for _, val := range []string{"0999", "rw", "01777"} {
+		_, err := New(WithLookuper(mapLookup(map[string]string{EnvServerUnixSocketMode: val})))
+		if !errors.Is(err, ErrInvalidFileMode) {
+			t.Errorf("New() with %s=%q error = %v, want %v", EnvServerUnixSocketMode, val, err, ErrInvalidFileMode)
+		}
+	}
+}
+
+func TestServerAddresses(t *testing.T) {
+	c, err := New(WithLookuper(mapLookup(map[string]string{
+		EnvServerAddress: " 127.0.0.1:8080 , [::1]:8080,unix:///run/app.sock,",
+	})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := []string{"127.0.0.1:8080", "[::1]:8080", "unix:///run/app.sock"}
+	if got := c.ServerAddresses(); !slices.Equal(got, want) {
+		t.Errorf("ServerAddresses() = %q, want %q", got, want)
+	}
+	wantEndpoints := []ServerEndpoint{{"tcp", "127.0.0.1:8080"}, {"tcp", "[::1]:8080"}, {"unix", "/run/app.sock"}}
+	if got := c.ServerEndpoints(); !slices.Equal(got, wantEndpoints) {
+		t.Errorf("ServerEndpoints() = %v, want %v", got, wantEndpoints)
+	}
+	if c.ServerAddress() != "127.0.0.1:8080" || c.ServerPort() != 8080 {
+		t.Errorf("got %q and %d, want the first address", c.ServerAddress(), c.ServerPort())
+	}
+}
+
+func TestServerAddressesErrors(t *testing.T) {
+	_, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: ":8080,localhost,host:99999"})))
+	if !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("New() error = %v, want %v", err, ErrInvalidAddress)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || !pe.Is(ErrInvalidAddress) {
+		t.Errorf("New() error = %v, want a ParseError of kind %v", err, ErrInvalidAddress)
+	}
+	for _, want := range []string{`(SERVER_ADDRESS) got="localhost"`, `(SERVER_ADDRESS) got="host:99999"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), `got=":8080"`) {
+		t.Errorf("error %q reports the valid entry", err)
+	}
+
+	_, err = New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: ", ,"})))
+	if !errors.Is(err, ErrInvalidAddress) || !strings.Contains(err.Error(), "missing address") {
+		t.Errorf("New() error = %v, want a missing address", err)
+	}
+	if !errors.As(err, &pe) || !pe.Is(ErrInvalidAddress) {
+		t.Errorf("New() error = %v, want a ParseError of kind %v", err, ErrInvalidAddress)
+	}
+}
+
+func TestServerAddressesDuplicates(t *testing.T) {
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: ":80,:8080,:http,unix:///a.sock,UNIX:///a.sock"})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if want := []string{":80", ":8080", "unix:///a.sock"}; !slices.Equal(c.ServerAddresses(), want) {
+		t.Errorf("ServerAddresses() = %q, want %q", c.ServerAddresses(), want)
+	}
+	want := []string{
+		`invalid configuration (SERVER_ADDRESS) got=":http" duplicate address`,
+		`invalid configuration (SERVER_ADDRESS) got="UNIX:///a.sock" duplicate address`,
+	}
+	if got := c.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Causes of the failures reported by [ParseByteSize].
+var (
+	errInvalidByteSize  = errors.New(`expected a number of bytes with an optional unit (e.g., "512KB", "10MiB")`)
+	errNegativeByteSize = errors.New("expected a non-negative number of bytes")
+	errByteSizeOverflow = errors.New("exceeds the maximum number of bytes")
+)
+
+// byteUnits maps the lower-cased byte size suffixes to their multipliers: the SI
+// units are powers of 1000 and the binary units powers of 1024.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseByteSize parses s as a number of bytes, either a bare integer (e.g.,
+// "1024") or a number followed by a unit (e.g., "512KB", "10MiB", "1.5MB").
+//
+// Units are case-insensitive and always interpreted the same way: the SI units
+// B, KB, MB, GB and TB are powers of 1000 (1KB = 1000 bytes), while the binary
+// units KiB, MiB, GiB and TiB are powers of 1024 (1KiB = 1024 bytes). Fractional
+// values are truncated to a whole number of bytes.
+//
+// Negative values and values exceeding [math.MaxInt64] bytes are errors.
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	num := strings.TrimRightFunc(s, func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	})
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[len(num):]))]
+	num = strings.TrimSpace(num)
+	if !ok || num == "" {
+		return 0, errInvalidByteSize
+	}
+	if strings.HasPrefix(num, "-") {
+		return 0, errNegativeByteSize
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(num, "+"), 10, 64)
+	if err == nil {
+		if n > math.MaxInt64/uint64(unit) {
+			return 0, errByteSizeOverflow
+		}
+		return int64(n) * unit, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange) && f != 0:
+		return 0, errByteSizeOverflow
+	case err != nil || math.IsNaN(f) || math.IsInf(f, 0):
+		return 0, errInvalidByteSize
+	}
+	if f *= float64(unit); f >= math.MaxInt64 {
+		return 0, errByteSizeOverflow
+	}
+	return int64(f), nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"+7", 7},
+		{" 42 ", 42},
+		{"1B", 1},
+		{"1KB", 1000},
+		{"1MB", 1000 * 1000},
+		{"1GB", 1000 * 1000 * 1000},
+		{"1TB", 1000 * 1000 * 1000 * 1000},
+		{"1KiB", 1 << 10},
+		{"1MiB", 1 << 20},
+		{"1GiB", 1 << 30},
+		{"1TiB", 1 << 40},
+		{"512kb", 512000},
+		{"10mib", 10 << 20},
+		{"2 GiB", 2 << 30},
+		{"1.5MB", 1500000},
+		{"1.5KiB", 1536},
+		{"0.5b", 0},
+		{".25KB", 250},
+		{strconv.FormatInt(math.MaxInt64, 10), math.MaxInt64},
+		{"8388607TiB", 8388607 << 40},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseByteSizeErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"", errInvalidByteSize},
+		{"KB", errInvalidByteSize},
+		{"lots", errInvalidByteSize},
+		{"1PB", errInvalidByteSize},
+		{"1 K B", errInvalidByteSize},
+		{"NaN", errInvalidByteSize},
+		{"-1", errNegativeByteSize},
+		{"-1.5MB", errNegativeByteSize},
+		{"9223372036854775808", errByteSizeOverflow},
+		{"99999999999999999999", errByteSizeOverflow},
+		{"8388608TiB", errByteSizeOverflow},
+		{"9223372036854775807KB", errByteSizeOverflow},
+		{"9300000000000000000.5", errByteSizeOverflow},
+	}
+	for _, tt := range tests {
+		if got, err := ParseByteSize(tt.in); !errors.Is(err, tt.want) {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestServerMaxHeaderBytes(t *testing.T) {
+	tests := []struct {
+		val  string
+		want int
+		kind error
+	}{
+		{"", DefaultServerMaxHeaderBytes, nil},
+		{"64KiB", 64 << 10, nil},
+		{"8192", 8192, nil},
+		{"0.5MB", 500000, nil},
+		{"0", DefaultServerMaxHeaderBytes, ErrOutOfRange},
+		{"4GB", DefaultServerMaxHeaderBytes, ErrOutOfRange},
+		{"lots", DefaultServerMaxHeaderBytes, ErrInvalidByteSize},
+		{"-1KB", DefaultServerMaxHeaderBytes, ErrInvalidByteSize},
+	}
+	for _, tt := range tests {
+		l := newLoader(t.Context(), []Option{WithLookuper(mapLookup(map[string]string{EnvServerMaxHeaderBytes: tt.val}))})
+		c := &Config{}
+		v := variableFor(EnvServerMaxHeaderBytes)
+		v.load(c, l, v)
+		if c.serverMaxHeaderBytes != tt.want {
+			t.Errorf("%s=%q loaded %d, want %d", EnvServerMaxHeaderBytes, tt.val, c.serverMaxHeaderBytes, tt.want)
+		}
+		checkKind(t, l.Err(), tt.kind)
+	}
+
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvServerMaxHeaderBytes: "2MiB"})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ServerMaxHeaderBytes() != 2<<20 {
+		t.Errorf("ServerMaxHeaderBytes() = %d, want %d", c.ServerMaxHeaderBytes(), 2<<20)
+	}
+}
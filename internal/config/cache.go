@@ -0,0 +1,46 @@
+package config
+
+import (
+	"sync"
+)
+
+var (
+	cacheMu sync.Mutex
+	cached  = newCachedLoad()
+)
+
+// Load returns the process-wide application configuration, loaded by [New]
+// without options on the first call and cached, along with its error, for every
+// later call. It is safe for concurrent use.
+//
+// Use [New] to load a fresh, uncached configuration.
+func Load() (*Config, error) {
+	cacheMu.Lock()
+	load := cached
+	cacheMu.Unlock()
+	return load()
+}
+
+// MustLoad is like [Load] but panics if the application configuration cannot be
+// loaded or validated. It is intended for use in main.
+func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// ResetForTesting clears the configuration cached by [Load], so that the next
+// call loads it again. It is intended for tests only.
+func ResetForTesting() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cached = newCachedLoad()
+}
+
+func newCachedLoad() func() (*Config, error) {
+	return sync.OnceValues(func() (*Config, error) {
+		return New()
+	})
+}
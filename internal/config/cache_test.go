@@ -0,0 +1,70 @@
+package config
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	setenv(t, map[string]string{EnvLogLevel: "warn"})
+	ResetForTesting()
+	t.Cleanup(ResetForTesting)
+
+	var (
+		wg   sync.WaitGroup
+		cfgs = make([]*Config, 16)
+	)
+	for i := range cfgs {
+		wg.Go(func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+			cfgs[i] = cfg
+		})
+	}
+	wg.Wait()
+	for _, cfg := range cfgs {
+		if cfg != cfgs[0] {
+			t.Fatal("concurrent Load() calls returned different instances")
+		}
+	}
+	if cfgs[0].LogLevel() != LogLevelWarn {
+		t.Errorf("LogLevel() = %v, want %v", cfgs[0].LogLevel(), LogLevelWarn)
+	}
+
+	t.Setenv(EnvLogLevel, "error")
+	if MustLoad() != cfgs[0] {
+		t.Error("MustLoad() did not return the cached instance")
+	}
+	ResetForTesting()
+	if cfg := MustLoad(); cfg == cfgs[0] || cfg.LogLevel() != LogLevelError {
+		t.Errorf("MustLoad() after ResetForTesting() = %v, want a fresh load", cfg)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	setenv(t, map[string]string{EnvLogLevel: "loud"})
+	ResetForTesting()
+	t.Cleanup(ResetForTesting)
+
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want an error")
+	}
+	t.Setenv(EnvLogLevel, "info")
+	if _, err := Load(); err == nil {
+		t.Error("Load() error = nil, want the cached error")
+	}
+	ResetForTesting()
+	if _, err := Load(); err != nil {
+		t.Errorf("Load() after ResetForTesting() error = %v", err)
+	}
+	t.Setenv(EnvLogLevel, "loud")
+	ResetForTesting()
+	defer func() {
+		if recover() == nil {
+			t.Error("MustLoad() did not panic")
+		}
+	}()
+	MustLoad()
+}
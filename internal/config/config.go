@@ -2,9 +2,14 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -57,6 +62,52 @@ const (
 	LogOutputStderr LogOutput = "stderr"
 )
 
+type (
+	// Environment represents the deployment environment the application runs in,
+	// selecting the set of defaults in effect.
+	Environment string
+)
+
+const (
+	// EnvironmentDevelopment selects the regular defaults, suited to running the
+	// application locally.
+	EnvironmentDevelopment Environment = "development"
+
+	// EnvironmentStaging selects the defaults suited to pre-production
+	// deployments.
+	EnvironmentStaging Environment = "staging"
+
+	// EnvironmentProduction selects the defaults suited to production
+	// deployments.
+	EnvironmentProduction Environment = "production"
+)
+
+type (
+	// Source represents the origin of a configuration value.
+	Source string
+)
+
+const (
+	// SourceDefault marks values falling back to the built-in defaults.
+	SourceDefault Source = "default"
+
+	// SourceEnv marks values read from the environment variables.
+	SourceEnv Source = "env"
+
+	// SourceFile marks values read from the configuration file, the env file or the
+	// configuration directory.
+	SourceFile Source = "file"
+
+	// SourceFlag marks values read from the command-line flags.
+	SourceFlag Source = "flag"
+
+	// SourceProvider marks values read from a [Provider].
+	SourceProvider Source = "provider"
+
+	// SourceOverride marks values set by an [Option] such as [WithLogLevel].
+	SourceOverride Source = "override"
+)
+
 const (
 	// EnvLogLevel specifies the environment variable name for configuring the
 	// [LogLevel].
@@ -95,9 +146,12 @@ const (
 	EnvLogOutput = "LOG_OUTPUT"
 
 	// EnvServerAddress specifies the environment variable name for configuring the
-	// server's address.
+	// server's address, or a comma-separated list of addresses to listen on
+	// simultaneously. Port 0 picks an ephemeral port when listening.
 	//
-	// Expected format: "<host>:port" (e.g., "localhost:8080", ":3000")
+	// Expected format: "<host>:port" (e.g., "localhost:8080", ":3000") or
+	// "unix://<path>" for a Unix domain socket (e.g., "unix:///run/mega.sock",
+	// "unix://./mega.sock")
 	//
 	// Default: [DefaultServerAddress]
 	EnvServerAddress = "SERVER_ADDRESS"
@@ -105,7 +159,7 @@ const (
 	// EnvServerReadTimeout specifies the environment variable name for configuring the
 	// server's read timeout.
 	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
+	// Expected format: [time.Duration] (e.g., "5s", "1m") or seconds (e.g., "30")
 	//
 	// Default: [DefaultServerReadTimeout]
 	EnvServerReadTimeout = "SERVER_READ_TIMEOUT"
@@ -113,7 +167,7 @@ const (
 	// EnvServerReadHeaderTimeout specifies the environment variable name for
 	// configuring the server's read header timeout.
 	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
+	// Expected format: [time.Duration] (e.g., "5s", "1m") or seconds (e.g., "30")
 	//
 	// Default: [DefaultServerReadHeaderTimeout]
 	EnvServerReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
@@ -121,7 +175,7 @@ const (
 	// EnvServerWriteTimeout specifies the environment variable name for configuring
 	// the server's write timeout.
 	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
+	// Expected format: [time.Duration] (e.g., "5s", "1m") or seconds (e.g., "30")
 	//
 	// Default: [DefaultServerWriteTimeout]
 	EnvServerWriteTimeout = "SERVER_WRITE_TIMEOUT"
@@ -129,7 +183,7 @@ const (
 	// EnvServerIdleTimeout specifies the environment variable name for configuring the
 	// server's idle timeout.
 	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
+	// Expected format: [time.Duration] (e.g., "5s", "1m") or seconds (e.g., "30")
 	//
 	// Default: [DefaultServerIdleTimeout]
 	EnvServerIdleTimeout = "SERVER_IDLE_TIMEOUT"
@@ -137,10 +191,90 @@ const (
 	// EnvServerShutdownTimeout specifies the environment variable name for configuring
 	// the server's shutdown timeout.
 	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
+	// Expected format: [time.Duration] (e.g., "5s", "1m") or seconds (e.g., "30")
 	//
 	// Default: [DefaultServerShutdownTimeout]
 	EnvServerShutdownTimeout = "SERVER_SHUTDOWN_TIMEOUT"
+
+	// EnvServerUnixSocketMode specifies the environment variable name for
+	// configuring the file mode of the Unix domain socket the server listens on,
+	// when [EnvServerAddress] names one.
+	//
+	// Expected format: octal file mode (e.g., "0660", "0600")
+	//
+	// Default: [DefaultServerUnixSocketMode]
+	EnvServerUnixSocketMode = "SERVER_UNIX_SOCKET_MODE"
+
+	// EnvServerMaxHeaderBytes specifies the environment variable name for
+	// configuring the maximum size of the request headers read by the server,
+	// including the request line.
+	//
+	// Expected format: byte size (e.g., "1MiB", "512KB", "65536"), see
+	// [ParseByteSize]
+	//
+	// Default: [DefaultServerMaxHeaderBytes]
+	EnvServerMaxHeaderBytes = "SERVER_MAX_HEADER_BYTES"
+
+	// EnvAppEnv specifies the environment variable name for configuring the
+	// [Environment]. Explicitly set variables and build-time defaults (see
+	// [DefaultLogLevelStr]) always take precedence over the defaults of the
+	// environment.
+	//
+	// Expected values:
+	//
+	//  - [EnvironmentDevelopment]
+	//  - [EnvironmentStaging] (defaults: [LogFormatJSON], "0.0.0.0:8080")
+	//  - [EnvironmentProduction] (defaults: [LogFormatJSON], "0.0.0.0:8080")
+	//
+	// Default: [DefaultEnvironment]
+	EnvAppEnv = "APP_ENV"
+
+	// EnvConfigEnvFile specifies the environment variable name for configuring the
+	// path of an env file whose entries apply to variables unset in the environment.
+	//
+	// Expected format: file path (e.g., ".env", "/etc/mega/mega.env")
+	//
+	// Default: [DefaultConfigEnvFile] (ignored when missing)
+	EnvConfigEnvFile = "CONFIG_ENV_FILE"
+
+	// EnvConfigFile specifies the environment variable name for configuring the
+	// path of an optional YAML configuration file layered under the environment.
+	//
+	// Expected format: file path (e.g., "/etc/mega/config.yaml")
+	EnvConfigFile = "CONFIG_FILE"
+
+	// EnvPrefix specifies the environment variable name for configuring the prefix
+	// prepended to every other environment variable name (see [EnvName]).
+	//
+	// Expected format: variable name prefix (e.g., "MYAPP")
+	//
+	// Default: no prefix
+	EnvPrefix = "ENV_PREFIX"
+
+	// EnvConfigStrict specifies the environment variable name for enabling the
+	// strict mode, in which every configuration variable without a value is an
+	// error instead of falling back to its default.
+	//
+	// Expected values: "true", "false"
+	//
+	// Default: [DefaultConfigStrict]
+	EnvConfigStrict = "CONFIG_STRICT"
+
+	// EnvConfigDir specifies the environment variable name for configuring the path
+	// of an optional directory in which each file holds the value of the variable
+	// it is named after (e.g., a Kubernetes projected volume).
+	//
+	// Expected format: directory path (e.g., "/etc/mega/config")
+	EnvConfigDir = "CONFIG_DIR"
+
+	// EnvConfigWatchInterval specifies the environment variable name for
+	// configuring how often [Manager.WatchFile] polls the configuration file named
+	// by [EnvConfigFile] for changes. Zero disables the polling.
+	//
+	// Expected format: [time.Duration] (e.g., "5s", "1m") or seconds (e.g., "30")
+	//
+	// Default: [DefaultConfigWatchInterval]
+	EnvConfigWatchInterval = "CONFIG_WATCH_INTERVAL"
 )
 
 const (
@@ -179,6 +313,32 @@ const (
 	// DefaultServerShutdownTimeout specifies the default server shutdown timeout, used
 	// as the fallback when [EnvServerShutdownTimeout] is unset.
 	DefaultServerShutdownTimeout = 15 * time.Second
+
+	// DefaultServerUnixSocketMode specifies the default file mode of the server's
+	// Unix domain socket, used as the fallback when [EnvServerUnixSocketMode] is
+	// unset.
+	DefaultServerUnixSocketMode fs.FileMode = 0o660
+
+	// DefaultServerMaxHeaderBytes specifies the default maximum size of the request
+	// headers read by the server, used as the fallback when
+	// [EnvServerMaxHeaderBytes] is unset.
+	DefaultServerMaxHeaderBytes = 1 << 20
+
+	// DefaultEnvironment specifies the default [Environment], used as the fallback
+	// when [EnvAppEnv] is unset.
+	DefaultEnvironment = EnvironmentDevelopment
+
+	// DefaultConfigEnvFile specifies the default env file path, used as the fallback
+	// when [EnvConfigEnvFile] is unset.
+	DefaultConfigEnvFile = ".env"
+
+	// DefaultConfigStrict specifies whether the strict mode is enabled by default,
+	// used as the fallback when [EnvConfigStrict] is unset.
+	DefaultConfigStrict = false
+
+	// DefaultConfigWatchInterval specifies the default configuration file polling
+	// interval, used as the fallback when [EnvConfigWatchInterval] is unset.
+	DefaultConfigWatchInterval = 5 * time.Second
 )
 
 const (
@@ -195,37 +355,177 @@ type (
 		logLevel                LogLevel
 		logFormat               LogFormat
 		logOutput               LogOutput
-		serverAddress           string
+		serverAddresses         []listenAddress
 		serverReadTimeout       time.Duration
 		serverReadHeaderTimeout time.Duration
 		serverWriteTimeout      time.Duration
 		serverIdleTimeout       time.Duration
 		serverShutdownTimeout   time.Duration
+		serverUnixSocketMode    fs.FileMode
+		serverMaxHeaderBytes    int
+		environment             Environment
+		sources                 map[string]Source
+		warnings                []string
+		configFile              string
+		watchInterval           time.Duration
 	}
 )
 
 // New creates and returns a new [Config] instance by loading and validating the
 // application configuration from the environment variables.
 //
+// Variables unset in the environment are read from the env file named by
+// [EnvConfigEnvFile], if any, then from the directory named by [EnvConfigDir]
+// (see [WithDirSource]), if any, and then from the configuration file named by
+// [EnvConfigFile], if any, and then from the providers registered with
+// [WithProviders], if any, before falling back to the defaults. When
+// [EnvPrefix] is set, variables are read as with [NewWithPrefix].
+//
+// Every variable may instead be provided through a sibling variable with the
+// "_FILE" suffix naming a file whose trimmed contents are the value (e.g.,
+// SERVER_ADDRESS_FILE=/run/secrets/address); setting both is an error.
+//
+// The environment is the process environment unless replaced with
+// [WithLookuper].
+//
 // If the application configuration cannot be loaded or validated, a single error
-// joining all failures is returned.
-func New() (*Config, error) {
-	l := newLoader()
-	cfg := &Config{
-		logLevel:                l.logLevel(),
-		logFormat:               l.logFormat(),
-		logOutput:               l.logOutput(),
-		serverAddress:           l.serverAddress(),
-		serverReadTimeout:       l.serverReadTimeout(),
-		serverReadHeaderTimeout: l.serverReadHeaderTimeout(),
-		serverWriteTimeout:      l.serverWriteTimeout(),
-		serverIdleTimeout:       l.serverIdleTimeout(),
-		serverShutdownTimeout:   l.serverShutdownTimeout(),
+// joining all failures, in loading order, is returned. Every failure is reported
+// as a [*ParseError] or a [*ValidationError].
+func New(opts ...Option) (*Config, error) {
+	return NewContext(context.Background(), opts...)
+}
+
+// NewContext creates and returns a new [Config] instance like [New], giving up
+// as soon as ctx is done. The context is passed to the providers registered with
+// [WithProviders] and checked before every file read.
+//
+// If ctx is done before the application configuration is loaded, the returned
+// error wraps ctx.Err() and no [Config] is returned.
+func NewContext(ctx context.Context, opts ...Option) (*Config, error) {
+	l := newLoader(ctx, opts)
+	if v, ok := l.lookup(EnvConfigFile); ok {
+		l.loadConfigFile(v.raw)
 	}
-	if err := l.Err(); err != nil {
-		return nil, fmt.Errorf("failed to load the application configuration: %w", err)
+	return l.load()
+}
+
+// NewWithPrefix creates and returns a new [Config] instance like [New], reading
+// every variable under its prefixed name (see [EnvName]) first and under its
+// unprefixed name when the prefixed one is unset. [EnvPrefix] is ignored.
+//
+// An empty prefix behaves exactly like [New] without [EnvPrefix].
+func NewWithPrefix(prefix string, opts ...Option) (*Config, error) {
+	return New(append(opts, WithPrefix(prefix))...)
+}
+
+// NewFromMap creates and returns a new [Config] instance by loading and validating
+// the application configuration from values, keyed by the Env* constants,
+// instead of the environment variables.
+//
+// Missing keys fall back to the defaults and present keys go through exactly the
+// same parsing and validation as the environment variables, including the
+// "_FILE" siblings. Unknown keys are reported as errors. No env file is
+// discovered implicitly and [EnvPrefix] is not consulted.
+func NewFromMap(values map[string]string, opts ...Option) (*Config, error) {
+	lookup := func(key string) (string, bool) {
+		val, ok := values[key]
+		return val, ok
 	}
-	return cfg, nil
+	opts = append(opts, WithLookuper(lookup), WithPrefix(""))
+	l := newLoader(context.Background(), opts)
+	known := slices.Clone(envKeys)
+	for _, key := range envKeys {
+		known = append(known, key+envFileSuffix)
+	}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !slices.Contains(known, key) {
+			l.addError(&ValidationError{Var: key, Reason: "unknown variable" + suggest(key, known), kind: ErrUnknownVariable})
+		}
+	}
+	if v, ok := l.lookup(EnvConfigFile); ok {
+		l.loadConfigFile(v.raw)
+	}
+	return l.load()
+}
+
+// NewFromFile creates and returns a new [Config] instance by loading and
+// validating the application configuration from the configuration file at path,
+// with the environment variables applied on top of it.
+//
+// Files with a ".json" extension are decoded as [FormatJSON], any other file as
+// [FormatYAML]. The file holds a "log" and a "server" section whose keys mirror the
+// environment variables without their prefix:
+//
+//	log:
+//	  level: debug
+//	  format: json
+//	server:
+//	  address: ":8080"
+//	  read_timeout: 5s
+//
+// In JSON files, durations may also be integer numbers of nanoseconds, the
+// encoding of [time.Duration]. Unknown keys are reported as errors. Like [New], a
+// single error joining all failures is returned.
+func NewFromFile(path string, opts ...Option) (*Config, error) {
+	l := newLoader(context.Background(), opts)
+	l.loadConfigFile(path)
+	return l.load()
+}
+
+// NewFromReader creates and returns a new [Config] instance by loading and
+// validating the application configuration from the configuration document read
+// from r in the given format, with the environment variables applied on top of
+// it.
+//
+// See [NewFromFile] for the document layout.
+func NewFromReader(r io.Reader, format Format, opts ...Option) (*Config, error) {
+	l := newLoader(context.Background(), opts)
+	l.loadConfigReader("reader", r, format)
+	return l.load()
+}
+
+// Validate loads and validates the application configuration like [New] with
+// [WithLookuper], or from the process environment when lookup is nil, and
+// returns the same error, discarding the [Config]. Warnings (see
+// [Config.Warnings]), such as unrecognized LOG_* and SERVER_* variables, are
+// reported as errors too. It is intended for entrypoint and CI checks.
+//
+// Since lookup cannot enumerate its variables, unrecognized variables are only
+// detected in the process environment and the env file.
+func Validate(lookup func(key string) (string, bool)) error {
+	_, err := New(WithLookuper(lookup), withWarningsAsErrors())
+	return err
+}
+
+// ValidateMap loads and validates the application configuration from values like
+// [NewFromMap] and returns the same error, discarding the [Config]. Unlike
+// [NewFromMap], only the keys in the LOG_* and SERVER_* namespaces that are not
+// configuration variables are reported, so that unrelated keys are allowed. They
+// are reported as errors along with the other warnings (see [Config.Warnings]).
+// It is intended for linting parsed env files.
+func ValidateMap(values map[string]string) error {
+	_, err := New(withMap(values), WithPrefix(""), withWarningsAsErrors())
+	return err
+}
+
+// EnvName returns the effective name of the environment variable envKey (one
+// of the Env* constants) under prefix, e.g. "MYAPP_LOG_LEVEL" for "MYAPP" and
+// [EnvLogLevel]. An empty prefix returns envKey unchanged.
+func EnvName(prefix, envKey string) string {
+	if prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "_"); prefix == "" {
+		return envKey
+	}
+	return prefix + "_" + envKey
+}
+
+// EnvNames returns the effective names of all the configuration variables under
+// prefix, in declaration order.
+func EnvNames(prefix string) []string {
+	names := make([]string, len(envKeys))
+	for i, envKey := range envKeys {
+		names[i] = EnvName(prefix, envKey)
+	}
+	return names
 }
 
 // LogLevel returns the configured severity or verbosity of log records.
@@ -243,9 +543,58 @@ func (c *Config) LogOutput() LogOutput {
 	return c.logOutput
 }
 
-// ServerAddress returns the configured server's address.
+// ServerAddresses returns the configured server's addresses, in canonical form
+// (e.g., "localhost:8080", "unix:///run/mega.sock").
+func (c *Config) ServerAddresses() []string {
+	addrs := make([]string, len(c.serverAddresses))
+	for i, a := range c.serverAddresses {
+		addrs[i] = a.String()
+	}
+	return addrs
+}
+
+// ServerEndpoints returns the configured server's addresses in the form expected
+// by [net.Listen].
+func (c *Config) ServerEndpoints() []ServerEndpoint {
+	endpoints := make([]ServerEndpoint, len(c.serverAddresses))
+	for i, a := range c.serverAddresses {
+		endpoints[i] = ServerEndpoint{Network: a.network, Address: a.address}
+	}
+	return endpoints
+}
+
+// ServerNetwork returns the network of the first configured server's address:
+// "tcp", or "unix" for a Unix domain socket.
+func (c *Config) ServerNetwork() string {
+	return c.serverAddress().network
+}
+
+// ServerAddress returns the first configured server's address, in the form
+// expected by [net.Listen] for [Config.ServerNetwork]: the path of the socket
+// for Unix domain sockets.
 func (c *Config) ServerAddress() string {
-	return c.serverAddress
+	return c.serverAddress().address
+}
+
+// ServerHost returns the host part of the first configured server's address,
+// empty when listening on all interfaces or on a Unix domain socket. IPv6
+// literals are returned without brackets.
+func (c *Config) ServerHost() string {
+	return c.serverAddress().host
+}
+
+// ServerPort returns the port of the first configured server's address, with
+// service names (e.g., "http") resolved to their number, or zero for a Unix
+// domain socket.
+func (c *Config) ServerPort() int {
+	return c.serverAddress().port
+}
+
+func (c *Config) serverAddress() listenAddress {
+	if len(c.serverAddresses) == 0 {
+		return listenAddress{}
+	}
+	return c.serverAddresses[0]
 }
 
 // ServerReadTimeout returns the configured server's read timeout.
@@ -273,334 +622,365 @@ func (c *Config) ServerShutdownTimeout() time.Duration {
 	return c.serverShutdownTimeout
 }
 
-type (
-	loader struct {
-		errs []error
-	}
-)
-
-func newLoader() *loader {
-	return &loader{}
+// ServerUnixSocketMode returns the configured file mode of the server's Unix
+// domain socket.
+func (c *Config) ServerUnixSocketMode() fs.FileMode {
+	return c.serverUnixSocketMode
 }
 
-func (l *loader) logLevel() LogLevel {
-	return ""
+// ServerMaxHeaderBytes returns the configured maximum size, in bytes, of the
+// request headers read by the server.
+func (c *Config) ServerMaxHeaderBytes() int {
+	return c.serverMaxHeaderBytes
 }
 
-func (l *loader) logFormat() LogFormat {
-	return ""
+// Environment returns the configured [Environment].
+func (c *Config) Environment() Environment {
+	return c.environment
 }
 
-func (l *loader) logOutput() LogOutput {
-	return ""
+// Source returns the origin of the value of the configuration variable envKey
+// (one of the Env* constants backing a [Config] field). Unknown variables report
+// [SourceDefault].
+func (c *Config) Source(envKey string) Source {
+	if src, ok := c.sources[envKey]; ok {
+		return src
+	}
+	return SourceDefault
 }
 
-func (l *loader) serverAddress() string {
-	return ""
+// Sources returns the origin of the value of every configuration variable
+// backing a [Config] field, keyed by the Env* constants.
+func (c *Config) Sources() map[string]Source {
+	return maps.Clone(c.sources)
 }
 
-func (l *loader) serverReadTimeout() time.Duration {
-	return 0
+// Warnings returns the non-fatal problems found while loading the application
+// configuration, such as unrecognized LOG_* and SERVER_* environment variables,
+// in the order they were found. Warnings never cause the load to fail, except in
+// the strict mode (see [EnvConfigStrict]), which promotes them to errors.
+func (c *Config) Warnings() []string {
+	return slices.Clone(c.warnings)
 }
 
-func (l *loader) serverReadHeaderTimeout() time.Duration {
-	return 0
-}
+// envFileSuffix is appended to a variable name to form the name of the sibling
+// variable pointing at a file that holds its value.
+const envFileSuffix = "_FILE"
 
-func (l *loader) serverWriteTimeout() time.Duration {
-	return 0
-}
+var (
+	logLevels = []string{
+		string(LogLevelDebug),
+		string(LogLevelInfo),
+		string(LogLevelWarn),
+		string(LogLevelError),
+	}
+	logFormats = []string{
+		string(LogFormatText),
+		string(LogFormatJSON),
+	}
+	logOutputs = []string{
+		string(LogOutputStdout),
+		string(LogOutputStderr),
+	}
+	environments = []string{
+		string(EnvironmentDevelopment),
+		string(EnvironmentStaging),
+		string(EnvironmentProduction),
+	}
+)
 
-func (l *loader) serverIdleTimeout() time.Duration {
-	return 0
-}
+type (
+	// value is a raw configuration value along with the name under which it was
+	// found (the option name for overrides, the flag name for command-line flags,
+	// the key for configuration files and the variable name otherwise) and its
+	// origin.
+	value struct {
+		raw       string
+		name      string
+		source    Source
+		sensitive bool
+	}
 
-func (l *loader) serverShutdownTimeout() time.Duration {
-	return 0
-}
+	override struct {
+		name  string
+		value string
+	}
 
-func (l *loader) loadEnum(envKey, defaultValue string, allowed ...string) string {
-	val := l.loadEnv(envKey, defaultValue)
-	idx := slices.IndexFunc(allowed, func(s string) bool {
-		return strings.EqualFold(val, s)
-	})
-	if idx >= 0 {
-		return allowed[idx]
+	loader struct {
+		ctx          context.Context
+		getenv       func(string) (string, bool)
+		environ      func() []string
+		ignored      []string
+		prefix       string
+		hasPrefix    bool
+		noEnvFile    bool
+		dir          string
+		dirFiles     map[string]string
+		configPath   string
+		strict       bool
+		hasStrict    bool
+		warnAsError  bool
+		optional     []string
+		overrides    map[string]override
+		flags        map[string]string
+		envFile      map[string]string
+		configFile   map[string]string
+		providers    []Provider
+		providerVals []providerValues
+		environment  Environment
+		sources      map[string]Source
+		errs         []error
+		warnings     []error
 	}
-	l.addErrorf("invalid configuration (%s) got=%q allowed=%v", envKey, val, allowed)
-	return defaultValue
-}
+)
 
-func (l *loader) loadEnv(envKey, defaultValue string) string {
-	if val := strings.TrimSpace(os.Getenv(envKey)); val != "" {
-		return val
+func newLoader(ctx context.Context, opts []Option) *loader {
+	l := &loader{
+		ctx:     ctx,
+		getenv:  os.LookupEnv,
+		environ: os.Environ,
+		ignored: ignoredVars,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if !l.hasPrefix {
+		l.prefix, _ = l.lookupVar(EnvPrefix)
+	}
+	l.loadEnvFile()
+	if l.dir == "" {
+		// Resolved once the env file is loaded, so that it may name the directory.
+		if v, ok := l.lookup(EnvConfigDir); ok {
+			l.dir = v.raw
+		}
+	}
+	if l.dir != "" {
+		l.loadDir()
 	}
-	return defaultValue
+	l.loadProviders()
+	if !l.hasStrict {
+		l.strict = l.lookupBool(variableFor(EnvConfigStrict))
+	}
+	l.environment = Environment(l.lookupEnum(variableFor(EnvAppEnv)))
+	return l
 }
 
-func (l *loader) addError(err error) {
+func (l *loader) loadEnvFile() {
+	path, name, explicit := l.lookupEnv(EnvConfigEnvFile)
+	if !explicit {
+		if l.noEnvFile {
+			return
+		}
+		path = DefaultConfigEnvFile
+	}
+	if l.ctx.Err() != nil {
+		return
+	}
+	f, err := os.Open(path)
 	if err != nil {
-		l.errs = append(l.errs, err)
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		l.addError(&ParseError{Var: name, Value: path, Err: err, kind: ErrInvalidFile})
+		return
 	}
+	defer f.Close()
+	vals, err := parseEnvFile(f)
+	if err != nil {
+		l.addParseErrors(path, err, ErrInvalidFile)
+	}
+	l.envFile = vals
 }
 
-func (l *loader) addErrorf(format string, args ...any) {
-	l.addError(fmt.Errorf(format, args...))
+func (l *loader) load() (*Config, error) {
+	if err := l.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load the application configuration: %w", err)
+	}
+	cfg := &Config{
+		environment:   l.environment,
+		configFile:    l.configPath,
+		watchInterval: l.lookupDuration(variableFor(EnvConfigWatchInterval)),
+	}
+	failed := make(map[string]bool)
+	for _, v := range registry {
+		if v.load != nil {
+			n := len(l.errs)
+			v.load(cfg, l, v)
+			failed[v.envKey] = len(l.errs) > n
+		}
+	}
+	l.checkConsistency(cfg, failed)
+	l.checkUnknown()
+	if l.strict || l.warnAsError {
+		l.errs = append(l.errs, l.warnings...)
+		l.warnings = nil
+	}
+	cfg.sources = l.sources
+	for _, w := range l.warnings {
+		cfg.warnings = append(cfg.warnings, w.Error())
+	}
+	if err := l.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load the application configuration: %w", err)
+	}
+	return cfg, nil
 }
 
-func (l *loader) Err() error {
-	return errors.Join(l.errs...)
+// shown returns the raw value for use in errors, redacted when sensitive.
+func (v value) shown() string {
+	if v.sensitive {
+		return redacted
+	}
+	return v.raw
+}
+
+// lookupField is like lookup for the variables backing [Config] fields, falling
+// back to the build-time defaults and then to the defaults of the [Environment].
+// It additionally records the [Source] of the value and, in strict mode, an
+// error for unset variables unless they are marked as optional.
+func (l *loader) lookupField(vr variable) (value, bool) {
+	envKey := vr.envKey
+	v, ok := l.lookup(envKey)
+	if !ok {
+		if l.strict && !slices.Contains(l.optional, envKey) {
+			l.addError(&ValidationError{Var: EnvName(l.prefix, envKey), Reason: "required variable is unset", kind: ErrMissingRequired})
+		}
+		v, ok = buildDefault(vr)
+		if !ok {
+			v, ok = l.profileDefault(envKey)
+		}
+		v.source = SourceDefault
+	}
+	if l.sources == nil {
+		l.sources = make(map[string]Source)
+	}
+	l.sources[envKey] = v.source
+	v.sensitive = vr.sensitive
+	return v, ok
 }
 
-// New() to Load()
-// create load(lookup)
-// defaultValue to fallback
-// loadEnv to get
-// loadServerAddress to serverAddress
-
-
-// Package config loads and provides the application configuration.
-package config
-
-import (
-	"time"
-)
-
-type (
-	// LogLevel represents the severity of log records.
-	LogLevel string
-)
-
-const (
-	// LogLevelDebug captures detailed information, typically useful for development
-	// and debugging.
-	LogLevelDebug LogLevel = "debug"
-
-	// LogLevelInfo captures general operational information.
-	LogLevelInfo LogLevel = "info"
-
-	// LogLevelWarn captures non-critical events or potentially harmful situations.
-	LogLevelWarn LogLevel = "warn"
-
-	// LogLevelError captures critical events or errors that require immediate
-	// attention.
-	LogLevelError LogLevel = "error"
-)
-
-type (
-	// LogFormat represents the encoding style of log records.
-	LogFormat string
-)
-
-const (
-	// LogFormatText renders log records as human-readable text.
-	LogFormatText LogFormat = "text"
-
-	// LogFormatJSON renders log records as structured JSON objects.
-	LogFormatJSON LogFormat = "json"
-)
-
-type (
-	// LogOutput represents the destination stream of log records.
-	LogOutput string
-)
-
-const (
-	// LogOutputStdout writes log records to the standard output stream (stdout).
-	LogOutputStdout LogOutput = "stdout"
-
-	// LogOutputStderr writes log records to the standard error stream (stderr).
-	LogOutputStderr LogOutput = "stderr"
-)
-
-const (
-	// EnvLogLevel specifies the environment variable name for configuring the
-	// [LogLevel].
-	//
-	// Expected values:
-	//
-	//  - [LogLevelDebug]
-	//  - [LogLevelInfo]
-	//  - [LogLevelWarn]
-	//  - [LogLevelError]
-	//
-	// Default: [DefaultLogLevel]
-	EnvLogLevel = "LOG_LEVEL"
-
-	// EnvLogFormat specifies the environment variable name for configuring the
-	// [LogFormat].
-	//
-	// Expected values:
-	//
-	//  - [LogFormatText]
-	//  - [LogFormatJSON]
-	//
-	// Default: [DefaultLogFormat]
-	EnvLogFormat = "LOG_FORMAT"
-
-	// EnvLogOutput specifies the environment variable name for configuring the
-	// [LogOutput].
-	//
-	// Expected values:
-	//
-	//  - [LogOutputStdout]
-	//  - [LogOutputStderr]
-	//  - A custom string (typically a file path)
-	//
-	// Default: [DefaultLogOutput]
-	EnvLogOutput = "LOG_OUTPUT"
-
-	// EnvServerAddress specifies the environment variable name for configuring the
-	// server address.
-	//
-	// Expected format: "<host>:port" (e.g., "localhost:8080", ":3000")
-	//
-	// Default: [DefaultServerAddress]
-	EnvServerAddress = "SERVER_ADDRESS"
-
-	// EnvServerReadTimeout specifies the environment variable name for configuring the
-	// server read timeout.
-	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
-	//
-	// Default: [DefaultServerReadTimeout]
-	EnvServerReadTimeout = "SERVER_READ_TIMEOUT"
-
-	// EnvServerReadHeaderTimeout specifies the environment variable name for
-	// configuring the server read header timeout.
-	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
-	//
-	// Default: [DefaultServerReadHeaderTimeout]
-	EnvServerReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
-
-	// EnvServerWriteTimeout specifies the environment variable name for configuring
-	// the server write timeout.
-	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
-	//
-	// Default: [DefaultServerWriteTimeout]
-	EnvServerWriteTimeout = "SERVER_WRITE_TIMEOUT"
-
-	// EnvServerIdleTimeout specifies the environment variable name for configuring the
-	// server idle timeout.
-	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
-	//
-	// Default: [DefaultServerIdleTimeout]
-	EnvServerIdleTimeout = "SERVER_IDLE_TIMEOUT"
-
-	// EnvServerShutdownTimeout specifies the environment variable name for configuring
-	// the server shutdown timeout.
-	//
-	// Expected format: [time.Duration] (e.g., "5s", "1m")
-	//
-	// Default: [DefaultServerShutdownTimeout]
-	EnvServerShutdownTimeout = "SERVER_SHUTDOWN_TIMEOUT"
-)
-
-const (
-	// DefaultLogLevel specifies the default [LogLevel], used as the fallback when
-	// [EnvLogLevel] is unset.
-	DefaultLogLevel = LogLevelInfo
-
-	// DefaultLogFormat specifies the default [LogFormat], used as the fallback when
-	// [EnvLogFormat] is unset.
-	DefaultLogFormat = LogFormatText
-
-	// DefaultLogOutput specifies the default [LogOutput], used as the fallback when
-	// [EnvLogOutput] is unset.
-	DefaultLogOutput = LogOutputStdout
-
-	// DefaultServerAddress specifies the default server address, used as the fallback
-	// when [EnvServerAddress] is unset.
-	DefaultServerAddress = "localhost:8080"
-
-	// DefaultServerReadTimeout specifies the default server read timeout, used as the
-	// fallback when [EnvServerReadTimeout] is unset.
-	DefaultServerReadTimeout = 5 * time.Second
-
-	// DefaultServerReadHeaderTimeout specifies the default server read header timeout,
-	// used as the fallback when [EnvServerReadHeaderTimeout] is unset.
-	DefaultServerReadHeaderTimeout = 2 * time.Second
-
-	// DefaultServerWriteTimeout specifies the default server write timeout, used as
-	// the fallback when [EnvServerWriteTimeout] is unset.
-	DefaultServerWriteTimeout = 10 * time.Second
-
-	// DefaultServerIdleTimeout specifies the default server idle timeout, used as the
-	// fallback when [EnvServerIdleTimeout] is unset.
-	DefaultServerIdleTimeout = 60 * time.Second
-
-	// DefaultServerShutdownTimeout specifies the default server shutdown timeout, used
-	// as the fallback when [EnvServerShutdownTimeout] is unset.
-	DefaultServerShutdownTimeout = 15 * time.Second
-)
-
-const (
-	// TCPPortMin defines the minimum port number for TCP connections.
-	TCPPortMin = 0
-
-	// TCPPortMax defines the maximum port number for TCP connections.
-	TCPPortMax = 65535
-)
-
-type (
-	// Config represents the immutable application configuration.
-	Config struct {
-		logLevel                LogLevel
-		logFormat               LogFormat
-		logOutput               LogOutput
-		serverAddress           string
-		serverReadTimeout       time.Duration
-		serverReadHeaderTimeout time.Duration
-		serverWriteTimeout      time.Duration
-		serverIdleTimeout       time.Duration
-		serverShutdownTimeout   time.Duration
+// lookup returns the value of envKey, taking the first one set among the
+// overrides, the command-line flags, the environment, the env file, the
+// configuration directory, the configuration file and the providers.
+func (l *loader) lookup(envKey string) (value, bool) {
+	if o, ok := l.overrides[envKey]; ok {
+		return value{raw: strings.TrimSpace(o.value), name: o.name, source: SourceOverride}, true
 	}
-)
-
-// LogLevel returns the configured severity of log records.
-func (c *Config) LogLevel() LogLevel {
-	return c.logLevel
+	if val := strings.TrimSpace(l.flags[envKey]); val != "" {
+		return value{raw: val, name: "-" + flagName(envKey), source: SourceFlag}, true
+	}
+	if val, name, ok := l.lookupEnv(envKey); ok {
+		return value{raw: val, name: name, source: SourceEnv}, true
+	}
+	for _, name := range l.envNames(envKey) {
+		if val, name, ok := l.lookupWithFile(name, l.lookupEnvFile); ok {
+			return value{raw: val, name: name, source: SourceFile}, true
+		}
+	}
+	for _, name := range l.envNames(envKey) {
+		if val := strings.TrimSpace(l.dirFiles[name]); val != "" {
+			return value{raw: val, name: filepath.Join(l.dir, name), source: SourceFile}, true
+		}
+	}
+	if val := strings.TrimSpace(l.configFile[envKey]); val != "" {
+		return value{raw: val, name: fileKey(envKey), source: SourceFile}, true
+	}
+	for _, p := range l.providerVals {
+		if val := strings.TrimSpace(p.vals[envKey]); val != "" {
+			return value{raw: val, name: envKey + " from " + p.name, source: SourceProvider}, true
+		}
+	}
+	return value{}, false
 }
 
-// LogFormat returns the configured encoding style of log records.
-func (c *Config) LogFormat() LogFormat {
-	return c.logFormat
+// lookupEnv returns the value of envKey in the environment, trying the prefixed
+// name before the unprefixed one.
+func (l *loader) lookupEnv(envKey string) (val, name string, ok bool) {
+	for _, name := range l.envNames(envKey) {
+		if val, name, ok := l.lookupWithFile(name, l.lookupVar); ok {
+			return val, name, true
+		}
+	}
+	return "", "", false
+}
+
+// lookupWithFile returns the value of the variable name using get, or the
+// trimmed contents of the file named by its name+"_FILE" sibling, along with the
+// name of the variable that provided it. Setting both variables is an error.
+func (l *loader) lookupWithFile(name string, get func(string) (string, bool)) (string, string, bool) {
+	fileName := name + envFileSuffix
+	val, ok := get(name)
+	path, fileOK := get(fileName)
+	switch {
+	case ok && fileOK:
+		l.addError(&ValidationError{Var: fileName, Reason: "conflicts with " + name, kind: ErrConflictingVariables})
+		return val, name, true
+	case fileOK:
+		if l.ctx.Err() != nil {
+			return "", "", false
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			l.addError(&ParseError{Var: fileName, Value: path, Err: err, kind: ErrInvalidFile})
+			return "", "", false
+		}
+		if val = strings.TrimSpace(string(b)); val == "" {
+			return "", "", false
+		}
+		return val, fileName, true
+	}
+	return val, name, ok
 }
 
-// LogOutput returns the configured destination stream of log records.
-func (c *Config) LogOutput() LogOutput {
-	return c.logOutput
+func (l *loader) lookupEnvFile(name string) (string, bool) {
+	val := strings.TrimSpace(l.envFile[name])
+	return val, val != ""
 }
 
-// ServerAddress returns the configured server address.
-func (c *Config) ServerAddress() string {
-	return c.serverAddress
+func (l *loader) envNames(envKey string) []string {
+	if name := EnvName(l.prefix, envKey); name != envKey {
+		return []string{name, envKey}
+	}
+	return []string{envKey}
 }
 
-// ServerReadTimeout returns the configured server read timeout.
-func (c *Config) ServerReadTimeout() time.Duration {
-	return c.serverReadTimeout
+// lookupVar returns the trimmed value of the environment variable name, treating
+// blank values as unset.
+func (l *loader) lookupVar(name string) (string, bool) {
+	val, ok := l.getenv(name)
+	if !ok {
+		return "", false
+	}
+	if val = strings.TrimSpace(val); val == "" {
+		return "", false
+	}
+	return val, true
 }
 
-// ServerReadHeaderTimeout returns the configured server read header timeout.
-func (c *Config) ServerReadHeaderTimeout() time.Duration {
-	return c.serverReadHeaderTimeout
+func (l *loader) addError(err error) {
+	if err != nil {
+		l.errs = append(l.errs, err)
+	}
 }
 
-// ServerWriteTimeout returns the configured server write timeout.
-func (c *Config) ServerWriteTimeout() time.Duration {
-	return c.serverWriteTimeout
+// addParseErrors records a [*ParseError] of the category kind found under name
+// for every error joined in err, so that each failure of a source can be
+// inspected on its own.
+func (l *loader) addParseErrors(name string, err, kind error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		l.addError(&ParseError{Var: name, Err: err, kind: kind})
+	}
 }
 
-// ServerIdleTimeout returns the configured server idle timeout.
-func (c *Config) ServerIdleTimeout() time.Duration {
-	return c.serverIdleTimeout
+// addWarning records a non-fatal problem, which is promoted to an error in
+// strict mode.
+func (l *loader) addWarning(err error) {
+	l.warnings = append(l.warnings, err)
 }
 
-// ServerShutdownTimeout returns the configured server shutdown timeout.
-func (c *Config) ServerShutdownTimeout() time.Duration {
-	return c.serverShutdownTimeout
+func (l *loader) Err() error {
+	return errors.Join(l.errs...)
 }
@@ -0,0 +1,669 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setenv isolates the test from the configuration variables of the process
+// environment and from any env file of the working directory, then sets env.
+func setenv(t *testing.T, env map[string]string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	for _, v := range registry {
+		t.Setenv(v.envKey, "")
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+// mapLookup returns a lookup function reading the variables from env.
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		bad   string
+		get   func(c *Config) any
+		def   any
+		set   any
+	}{
+		{"log level", EnvLogLevel, "debug", "bogus", func(c *Config) any { return c.LogLevel() }, DefaultLogLevel, LogLevelDebug},
+		{"log format", EnvLogFormat, "json", "bogus", func(c *Config) any { return c.LogFormat() }, DefaultLogFormat, LogFormatJSON},
+		{"log output", EnvLogOutput, "stderr", "", func(c *Config) any { return c.LogOutput() }, DefaultLogOutput, LogOutputStderr},
+		{"server address", EnvServerAddress, ":9090", "bogus", func(c *Config) any { return c.ServerAddress() }, DefaultServerAddress, ":9090"},
+		{"server read timeout", EnvServerReadTimeout, "7s", "bogus", func(c *Config) any { return c.ServerReadTimeout() }, DefaultServerReadTimeout, 7 * time.Second},
+		{"server read header timeout", EnvServerReadHeaderTimeout, "1s", "bogus", func(c *Config) any { return c.ServerReadHeaderTimeout() }, DefaultServerReadHeaderTimeout, time.Second},
+		{"server write timeout", EnvServerWriteTimeout, "30s", "bogus", func(c *Config) any { return c.ServerWriteTimeout() }, DefaultServerWriteTimeout, 30 * time.Second},
+		{"server idle timeout", EnvServerIdleTimeout, "2m", "bogus", func(c *Config) any { return c.ServerIdleTimeout() }, DefaultServerIdleTimeout, 2 * time.Minute},
+		{"server shutdown timeout", EnvServerShutdownTimeout, "20s", "bogus", func(c *Config) any { return c.ServerShutdownTimeout() }, DefaultServerShutdownTimeout, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/unset", func(t *testing.T) {
+			setenv(t, nil)
+			c, err := New()
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := tt.get(c); got != tt.def {
+				t.Errorf("got %v, want default %v", got, tt.def)
+			}
+		})
+		t.Run(tt.name+"/valid", func(t *testing.T) {
+			setenv(t, map[string]string{tt.key: tt.value})
+			c, err := New()
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := tt.get(c); got != tt.set {
+				t.Errorf("got %v, want %v", got, tt.set)
+			}
+		})
+		if tt.bad == "" {
+			continue
+		}
+		t.Run(tt.name+"/invalid", func(t *testing.T) {
+			setenv(t, map[string]string{tt.key: tt.bad})
+			c, err := New()
+			if err == nil {
+				t.Fatalf("New() = %v, want an error", c)
+			}
+			for _, want := range []string{tt.key, tt.bad} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewJoinsErrors(t *testing.T) {
+	setenv(t, map[string]string{
+		EnvLogLevel:          "loud",
+		EnvLogFormat:         "xml",
+		EnvServerReadTimeout: "soon",
+	})
+	_, err := New()
+	if err == nil {
+		t.Fatal("New() error = nil, want an error")
+	}
+	for _, want := range []string{
+		`(LOG_LEVEL) got="loud"`,
+		`(LOG_FORMAT) got="xml"`,
+		`(SERVER_READ_TIMEOUT) got="soon"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestNewWithPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		env    map[string]string
+		want   LogLevel
+	}{
+		{"prefixed wins", "MYAPP", map[string]string{"MYAPP_LOG_LEVEL": "debug", "LOG_LEVEL": "error"}, LogLevelDebug},
+		{"unprefixed fallback", "MYAPP", map[string]string{"LOG_LEVEL": "error"}, LogLevelError},
+		{"blank prefixed is unset", "MYAPP", map[string]string{"MYAPP_LOG_LEVEL": " ", "LOG_LEVEL": "error"}, LogLevelError},
+		{"default", "MYAPP", nil, DefaultLogLevel},
+		{"trailing underscore", "MYAPP_", map[string]string{"MYAPP_LOG_LEVEL": "warn"}, LogLevelWarn},
+		{"empty prefix", "", map[string]string{"LOG_LEVEL": "warn", "_LOG_LEVEL": "debug"}, LogLevelWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := NewWithPrefix(tt.prefix, WithLookuper(mapLookup(tt.env)))
+			if err != nil {
+				t.Fatalf("NewWithPrefix() error = %v", err)
+			}
+			if c.LogLevel() != tt.want {
+				t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWithPrefixErrorNames(t *testing.T) {
+	t.Parallel()
+	_, err := NewWithPrefix("MYAPP", WithLookuper(mapLookup(map[string]string{
+		"MYAPP_LOG_LEVEL": "loud",
+		"LOG_FORMAT":      "xml",
+	})))
+	if err == nil {
+		t.Fatal("NewWithPrefix() error = nil, want an error")
+	}
+	for _, want := range []string{"(MYAPP_LOG_LEVEL)", "(LOG_FORMAT)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %s", err, want)
+		}
+	}
+}
+
+func TestNewEnvPrefix(t *testing.T) {
+	t.Parallel()
+	c, err := New(WithLookuper(mapLookup(map[string]string{
+		EnvPrefix:         "SVC",
+		"SVC_LOG_LEVEL":   "debug",
+		"OTHER_LOG_LEVEL": "error",
+	})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelDebug {
+		t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), LogLevelDebug)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", EnvLogLevel},
+		{" ", EnvLogLevel},
+		{"MYAPP", "MYAPP_LOG_LEVEL"},
+		{"MYAPP_", "MYAPP_LOG_LEVEL"},
+	}
+	for _, tt := range tests {
+		if got := EnvName(tt.prefix, EnvLogLevel); got != tt.want {
+			t.Errorf("EnvName(%q, %q) = %q, want %q", tt.prefix, EnvLogLevel, got, tt.want)
+		}
+	}
+	names := EnvNames("MYAPP")
+	if len(names) != len(envKeys) {
+		t.Fatalf("EnvNames() returned %d names, want %d", len(names), len(envKeys))
+	}
+	for i, name := range names {
+		if want := "MYAPP_" + envKeys[i]; name != want {
+			t.Errorf("EnvNames()[%d] = %q, want %q", i, name, want)
+		}
+	}
+}
+
+func TestNewFromMap(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		c, err := NewFromMap(nil)
+		if err != nil {
+			t.Fatalf("NewFromMap() error = %v", err)
+		}
+		for envKey, src := range c.Sources() {
+			if src != SourceDefault {
+				t.Errorf("Source(%s) = %v, want %v", envKey, src, SourceDefault)
+			}
+		}
+		if c.LogLevel() != DefaultLogLevel || c.ServerAddress() != DefaultServerAddress || c.ServerIdleTimeout() != DefaultServerIdleTimeout {
+			t.Errorf("NewFromMap(nil) = %v, want the defaults", c)
+		}
+	})
+	t.Run("full", func(t *testing.T) {
+		t.Parallel()
+		c, err := NewFromMap(map[string]string{
+			EnvLogLevel:                "debug",
+			EnvLogFormat:               "json",
+			EnvLogOutput:               "stderr",
+			EnvServerAddress:           ":9000",
+			EnvServerReadTimeout:       "1s",
+			EnvServerReadHeaderTimeout: "1s",
+			EnvServerWriteTimeout:      "2s",
+			EnvServerIdleTimeout:       "3s",
+			EnvServerShutdownTimeout:   "4s",
+		})
+		if err != nil {
+			t.Fatalf("NewFromMap() error = %v", err)
+		}
+		want := `{"app":{"env":"development"},"log":{"format":"json","level":"debug","output":"stderr"},` +
+			`"server":{"address":":9000","idle_timeout":"3s","max_header_bytes":"1048576","read_header_timeout":"1s",` +
+			`"read_timeout":"1s","shutdown_timeout":"4s","unix_socket_mode":"0660","write_timeout":"2s"}}`
+		if got := c.String(); got != want {
+			t.Errorf("NewFromMap() = %s, want %s", got, want)
+		}
+	})
+	t.Run("unknown keys", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewFromMap(map[string]string{"LOG_LEVL": "debug", "DATABASE_URL": "x"})
+		if err == nil {
+			t.Fatal("NewFromMap() error = nil, want an error")
+		}
+		for _, want := range []string{"(LOG_LEVL) unknown variable, did you mean LOG_LEVEL?", "(DATABASE_URL) unknown variable"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+	})
+	t.Run("file variables", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "level")
+		if err := os.WriteFile(path, []byte("warn\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewFromMap(map[string]string{EnvLogLevel + "_FILE": path})
+		if err != nil {
+			t.Fatalf("NewFromMap() error = %v", err)
+		}
+		if c.LogLevel() != LogLevelWarn {
+			t.Errorf("LogLevel() = %v, want the file value %v", c.LogLevel(), LogLevelWarn)
+		}
+		_, err = NewFromMap(map[string]string{"LOG_LEVL_FILE": path})
+		if want := "(LOG_LEVL_FILE) unknown variable, did you mean LOG_LEVEL_FILE?"; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("NewFromMap() error = %v, want one containing %q", err, want)
+		}
+	})
+}
+
+func TestNewFromMapErrorsMatchEnvironment(t *testing.T) {
+	values := map[string]string{
+		EnvLogLevel:           "loud",
+		EnvServerAddress:      ":99999",
+		EnvServerWriteTimeout: "-1s",
+	}
+	setenv(t, values)
+	_, want := New()
+	_, got := NewFromMap(values)
+	if want == nil || got == nil {
+		t.Fatalf("got errors %v and %v, want both set", got, want)
+	}
+	if got.Error() != want.Error() {
+		t.Errorf("NewFromMap() error = %q, want the environment error %q", got, want)
+	}
+}
+
+// fullEnv returns a valid value for every variable backing a [Config] field.
+func fullEnv() map[string]string {
+	return map[string]string{
+		EnvLogLevel:                "info",
+		EnvLogFormat:               "json",
+		EnvLogOutput:               "stdout",
+		EnvServerAddress:           ":8080",
+		EnvServerReadTimeout:       "5s",
+		EnvServerReadHeaderTimeout: "2s",
+		EnvServerWriteTimeout:      "10s",
+		EnvServerIdleTimeout:       "60s",
+		EnvServerShutdownTimeout:   "15s",
+		EnvServerUnixSocketMode:    "0660",
+		EnvServerMaxHeaderBytes:    "1MiB",
+	}
+}
+
+func TestStrict(t *testing.T) {
+	t.Run("everything set", func(t *testing.T) {
+		t.Parallel()
+		if _, err := New(WithLookuper(mapLookup(fullEnv())), Strict()); err != nil {
+			t.Errorf("New() error = %v", err)
+		}
+	})
+	t.Run("several unset", func(t *testing.T) {
+		t.Parallel()
+		env := fullEnv()
+		delete(env, EnvServerAddress)
+		delete(env, EnvLogLevel)
+		_, err := New(WithLookuper(mapLookup(env)), Strict())
+		if !errors.Is(err, ErrMissingRequired) {
+			t.Fatalf("New() error = %v, want %v", err, ErrMissingRequired)
+		}
+		for _, want := range []string{"(LOG_LEVEL) required variable is unset", "(SERVER_ADDRESS) required variable is unset"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+	})
+	t.Run("optional", func(t *testing.T) {
+		t.Parallel()
+		env := fullEnv()
+		delete(env, EnvLogFormat)
+		c, err := New(WithLookuper(mapLookup(env)), Strict(), Optional(EnvLogFormat))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if c.LogFormat() != DefaultLogFormat {
+			t.Errorf("LogFormat() = %v, want the default %v", c.LogFormat(), DefaultLogFormat)
+		}
+	})
+	t.Run("variable", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(WithLookuper(mapLookup(map[string]string{EnvConfigStrict: "true"})))
+		if !errors.Is(err, ErrMissingRequired) {
+			t.Errorf("New() error = %v, want %v", err, ErrMissingRequired)
+		}
+	})
+	t.Run("prefixed names", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewWithPrefix("MYAPP", WithLookuper(mapLookup(nil)), Strict())
+		if err == nil || !strings.Contains(err.Error(), "(MYAPP_SERVER_ADDRESS) required variable is unset") {
+			t.Errorf("NewWithPrefix() error = %v, want the prefixed name", err)
+		}
+	})
+	t.Run("non-strict", func(t *testing.T) {
+		t.Parallel()
+		if _, err := New(WithLookuper(mapLookup(map[string]string{EnvConfigStrict: "false"}))); err != nil {
+			t.Errorf("New() error = %v", err)
+		}
+	})
+}
+
+func TestSources(t *testing.T) {
+	setenv(t, map[string]string{
+		EnvLogLevel:           "warn",
+		EnvLogFormat:          "json",
+		EnvServerWriteTimeout: "30s",
+		EnvConfigFile:         "config.yaml",
+	})
+	writeFile(t, ".env", "LOG_OUTPUT=stderr\nSERVER_WRITE_TIMEOUT=40s\n")
+	writeFile(t, "config.yaml", "server:\n  read_timeout: 3s\n  write_timeout: 50s\n")
+	fs := newFlagSet()
+	if err := fs.Parse([]string{"-log-format=text"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewWithFlags(fs, WithServerAddress(":0"), WithLogLevel(LogLevelError))
+	if err != nil {
+		t.Fatalf("NewWithFlags() error = %v", err)
+	}
+	want := map[string]Source{
+		EnvLogLevel:                SourceOverride,
+		EnvLogFormat:               SourceFlag,
+		EnvLogOutput:               SourceFile,
+		EnvServerAddress:           SourceOverride,
+		EnvServerReadTimeout:       SourceFile,
+		EnvServerReadHeaderTimeout: SourceDefault,
+		EnvServerWriteTimeout:      SourceEnv,
+		EnvServerIdleTimeout:       SourceDefault,
+		EnvServerShutdownTimeout:   SourceDefault,
+		EnvServerUnixSocketMode:    SourceDefault,
+		EnvServerMaxHeaderBytes:    SourceDefault,
+	}
+	got := c.Sources()
+	for _, v := range registry {
+		if v.load != nil && got[v.envKey] != want[v.envKey] {
+			t.Errorf("Source(%s) = %q, want %q", v.envKey, got[v.envKey], want[v.envKey])
+		}
+	}
+	if c.Source(EnvConfigFile) != SourceDefault {
+		t.Errorf("Source(%s) = %q, want %q for variables backing no field", EnvConfigFile, c.Source(EnvConfigFile), SourceDefault)
+	}
+	got[EnvLogLevel] = SourceEnv
+	if c.Source(EnvLogLevel) != SourceOverride {
+		t.Error("Sources() returned the internal map")
+	}
+}
+
+func TestFileVariables(t *testing.T) {
+	dir := t.TempDir()
+	secret := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	address := secret("address", ":9000\n")
+	level := secret("level", "  debug \r\n")
+	empty := secret("empty", "\n")
+	invalid := secret("invalid", "loud\n")
+	missing := filepath.Join(dir, "missing")
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		c, err := New(WithLookuper(mapLookup(map[string]string{
+			"SERVER_ADDRESS_FILE": address,
+			"LOG_LEVEL_FILE":      level,
+			"LOG_FORMAT_FILE":     empty,
+		})))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if c.ServerAddress() != ":9000" || c.LogLevel() != LogLevelDebug || c.LogFormat() != DefaultLogFormat {
+			t.Errorf("got %v, %v and %v, want :9000, %v and %v", c.ServerAddress(), c.LogLevel(), c.LogFormat(), LogLevelDebug, DefaultLogFormat)
+		}
+	})
+	t.Run("both set", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(WithLookuper(mapLookup(map[string]string{
+			"SERVER_ADDRESS":      ":8000",
+			"SERVER_ADDRESS_FILE": address,
+		})))
+		if !errors.Is(err, ErrConflictingVariables) || !strings.Contains(err.Error(), "(SERVER_ADDRESS_FILE) conflicts with SERVER_ADDRESS") {
+			t.Errorf("New() error = %v, want a conflict", err)
+		}
+	})
+	t.Run("unreadable", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(WithLookuper(mapLookup(map[string]string{"LOG_LEVEL_FILE": missing})))
+		if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), missing) {
+			t.Errorf("New() error = %v, want one naming %s", err, missing)
+		}
+	})
+	t.Run("validated", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(WithLookuper(mapLookup(map[string]string{"LOG_LEVEL_FILE": invalid})))
+		if !errors.Is(err, ErrInvalidLogLevel) || !strings.Contains(err.Error(), `(LOG_LEVEL_FILE) got="loud"`) {
+			t.Errorf("New() error = %v, want an invalid log level", err)
+		}
+	})
+	t.Run("env file", func(t *testing.T) {
+		setenv(t, nil)
+		writeFile(t, ".env", "LOG_LEVEL_FILE="+level+"\n")
+		c, err := New()
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if c.LogLevel() != LogLevelDebug {
+			t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), LogLevelDebug)
+		}
+	})
+}
+
+// slowProvider is a [Provider] ignoring its context and returning only once
+// release is closed.
+type slowProvider struct {
+	release chan struct{}
+}
+
+func (p slowProvider) Load(context.Context) (map[string]string, error) {
+	<-p.release
+	return map[string]string{EnvLogLevel: "debug"}, nil
+}
+
+func TestNewContext(t *testing.T) {
+	p := slowProvider{release: make(chan struct{})}
+	defer close(p.release)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	c, err := NewContext(ctx, WithLookuper(mapLookup(nil)), WithProviders(p))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("NewContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if c != nil {
+		t.Errorf("NewContext() = %v, want no Config", c)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("NewContext() took %v, want it to return at the deadline", elapsed)
+	}
+}
+
+func TestNewContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "level")
+	writeFile(t, path, "debug")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for name, opt := range map[string]Option{
+		"file variable": WithLookuper(mapLookup(map[string]string{EnvLogLevel + "_FILE": path})),
+		"dir source":    WithDirSource(dir),
+		"config file":   WithLookuper(mapLookup(map[string]string{EnvConfigFile: filepath.Join(dir, "config.yaml")})),
+	} {
+		c, err := NewContext(ctx, WithLookuper(mapLookup(nil)), opt)
+		if !errors.Is(err, context.Canceled) || c != nil {
+			t.Errorf("NewContext() with a %s = %v, %v, want no Config and %v", name, c, err, context.Canceled)
+		}
+	}
+	if _, err := NewContext(context.Background(), WithLookuper(mapLookup(nil))); err != nil {
+		t.Errorf("NewContext() error = %v", err)
+	}
+}
+
+func TestValidateParity(t *testing.T) {
+	for _, env := range []map[string]string{
+		{EnvLogLevel: "loud"},
+		{EnvLogFormat: "xml", EnvServerReadTimeout: "soon"},
+		{EnvServerAddress: "nowhere", EnvServerIdleTimeout: "-1s"},
+		{EnvServerReadTimeout: "1s", EnvServerReadHeaderTimeout: "2s"},
+		{EnvAppEnv: "prod"},
+	} {
+		_, want := New(WithLookuper(mapLookup(env)))
+		if want == nil {
+			t.Fatalf("New(%v) error = nil, want an error", env)
+		}
+		if err := Validate(mapLookup(env)); err == nil || err.Error() != want.Error() {
+			t.Errorf("Validate(%v) error = %v, want %v", env, err, want)
+		}
+		_, want = NewFromMap(env)
+		if err := ValidateMap(env); err == nil || err.Error() != want.Error() {
+			t.Errorf("ValidateMap(%v) error = %v, want %v", env, err, want)
+		}
+	}
+	if err := Validate(mapLookup(fullEnv())); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := ValidateMap(fullEnv()); err != nil {
+		t.Errorf("ValidateMap() error = %v", err)
+	}
+}
+
+func TestValidateUnknownVariables(t *testing.T) {
+	setenv(t, map[string]string{"SERVER_WRITE_TIMOUT": "30s", "DATABASE_URL": "postgres://"})
+	err := Validate(nil)
+	if !errors.Is(err, ErrUnknownVariable) {
+		t.Fatalf("Validate() error = %v, want %v", err, ErrUnknownVariable)
+	}
+	if want := "(SERVER_WRITE_TIMOUT) unknown variable, did you mean SERVER_WRITE_TIMEOUT?"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not contain %q", err, want)
+	}
+
+	err = ValidateMap(map[string]string{"DATABASE_URL": "postgres://", "HOME": "/root", "LOG_LEVL": "debug"})
+	if !errors.Is(err, ErrUnknownVariable) {
+		t.Fatalf("ValidateMap() error = %v, want %v", err, ErrUnknownVariable)
+	}
+	if strings.Contains(err.Error(), "DATABASE_URL") || strings.Contains(err.Error(), "HOME") {
+		t.Errorf("ValidateMap() error = %v, want unrelated keys allowed", err)
+	}
+	if err := ValidateMap(map[string]string{"DATABASE_URL": "postgres://", EnvLogLevel: "debug"}); err != nil {
+		t.Errorf("ValidateMap() error = %v, want unrelated keys allowed", err)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	env := map[string]string{
+		EnvServerIdleTimeout:  "0",
+		EnvServerWriteTimeout: "48h",
+	}
+	want := []string{
+		`invalid configuration (SERVER_WRITE_TIMEOUT) got="48h0m0s" exceeds 24h0m0s`,
+		`invalid configuration (SERVER_IDLE_TIMEOUT) got="0s" disables the timeout`,
+		`invalid configuration (SERVER_SHUTDOWN_TIMEOUT) got="15s" shorter than SERVER_WRITE_TIMEOUT=48h0m0s`,
+	}
+	c, err := New(WithLookuper(mapLookup(env)))
+	if err != nil {
+		t.Fatalf("New() error = %v, want warnings only", err)
+	}
+	if got := c.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+
+	mixed := maps.Clone(env)
+	mixed[EnvLogLevel] = "loud"
+	_, err = New(WithLookuper(mapLookup(mixed)))
+	if !errors.Is(err, ErrInvalidLogLevel) {
+		t.Fatalf("New() error = %v, want %v", err, ErrInvalidLogLevel)
+	}
+	if errors.Is(err, ErrOutOfRange) {
+		t.Errorf("New() error = %v, want the warnings left out", err)
+	}
+
+	strict := maps.Clone(env)
+	strict[EnvConfigStrict] = "true"
+	_, err = New(WithLookuper(mapLookup(strict)), Optional(envKeys...))
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("New() in strict mode error = %v, want %v", err, ErrOutOfRange)
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error %q does not contain %q", err, w)
+		}
+	}
+}
+
+func TestEnumParsing(t *testing.T) {
+	tests := []struct {
+		envKey string
+		val    string
+		want   string
+	}{
+		{EnvLogLevel, "INFO", string(LogLevelInfo)},
+		{EnvLogLevel, "Debug", string(LogLevelDebug)},
+		{EnvLogLevel, "\twarn\t", string(LogLevelWarn)},
+		{EnvLogLevel, `"error"`, string(LogLevelError)},
+		{EnvLogLevel, `' Error '`, string(LogLevelError)},
+		{EnvLogFormat, ` "json" `, string(LogFormatJSON)},
+		{EnvLogFormat, "TeXt", string(LogFormatText)},
+		{EnvLogOutput, "STDERR", string(LogOutputStderr)},
+		{EnvLogOutput, `'stdout'`, string(LogOutputStdout)},
+		{EnvAppEnv, "PRODUCTION", string(EnvironmentProduction)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.envKey+"="+tt.val, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(WithLookuper(mapLookup(map[string]string{tt.envKey: tt.val})))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got := map[string]string{
+				EnvLogLevel:  string(c.LogLevel()),
+				EnvLogFormat: string(c.LogFormat()),
+				EnvLogOutput: string(c.LogOutput()),
+				EnvAppEnv:    string(c.Environment()),
+			}[tt.envKey]
+			if got != tt.want {
+				t.Errorf("%s = %q, want the canonical %q", tt.envKey, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnumParsingInvalid(t *testing.T) {
+	tests := []struct {
+		envKey string
+		val    string
+		want   string
+	}{
+		{EnvLogLevel, "verbose", `(LOG_LEVEL) got="verbose" allowed=[debug info warn error]`},
+		{EnvLogLevel, `"info`, `(LOG_LEVEL) got="\"info" allowed=[debug info warn error]`},
+		{EnvLogFormat, "x ml", `(LOG_FORMAT) got="x ml" allowed=[text json]`},
+	}
+	for _, tt := range tests {
+		_, err := New(WithLookuper(mapLookup(map[string]string{tt.envKey: tt.val})))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New() with %s=%q error = %v, want one containing %q", tt.envKey, tt.val, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"time"
+)
+
+type (
+	// durationRule requires the duration of the variable envKey to be no longer
+	// (or, when longer is set, no shorter) than the one of the variable other,
+	// unless either is zero (no timeout). Violations are errors, or warnings when
+	// warn is set.
+	durationRule struct {
+		envKey string
+		other  string
+		get    func(c *Config) (d, other time.Duration)
+		longer bool
+		warn   bool
+	}
+)
+
+// durationRules lists the consistency rules between related durations.
+var durationRules = []durationRule{
+	{
+		envKey: EnvServerReadHeaderTimeout,
+		other:  EnvServerReadTimeout,
+		get: func(c *Config) (time.Duration, time.Duration) {
+			return c.serverReadHeaderTimeout, c.serverReadTimeout
+		},
+	},
+	{
+		envKey: EnvServerShutdownTimeout,
+		other:  EnvServerWriteTimeout,
+		get: func(c *Config) (time.Duration, time.Duration) {
+			return c.serverShutdownTimeout, c.serverWriteTimeout
+		},
+		longer: true,
+		warn:   true,
+	},
+	{
+		envKey: EnvServerIdleTimeout,
+		other:  EnvServerReadTimeout,
+		get: func(c *Config) (time.Duration, time.Duration) {
+			return c.serverIdleTimeout, c.serverReadTimeout
+		},
+		longer: true,
+		warn:   true,
+	},
+}
+
+// checkConsistency applies the rules between related durations of c, skipping
+// those involving a variable listed in failed so that an invalid value is not
+// reported again as an inconsistency.
+func (l *loader) checkConsistency(c *Config, failed map[string]bool) {
+	for _, r := range durationRules {
+		if failed[r.envKey] || failed[r.other] {
+			continue
+		}
+		d, other := r.get(c)
+		if d == 0 || other == 0 {
+			continue
+		}
+		var err *ValidationError
+		switch {
+		case r.longer && d < other:
+			err = l.inconsistency(r, d, "shorter than", other)
+		case !r.longer && d > other:
+			err = l.inconsistency(r, d, "longer than", other)
+		default:
+			continue
+		}
+		if r.warn {
+			l.addWarning(err)
+		} else {
+			l.addError(err)
+		}
+	}
+}
+
+func (l *loader) inconsistency(r durationRule, d time.Duration, relation string, other time.Duration) *ValidationError {
+	return &ValidationError{
+		Var:    EnvName(l.prefix, r.envKey),
+		Value:  d.String(),
+		Reason: relation + " " + EnvName(l.prefix, r.other) + "=" + other.String(),
+		kind:   ErrInconsistentValues,
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConsistency(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		err     string
+		warning string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "read header equal to read",
+			env:  map[string]string{EnvServerReadTimeout: "3s", EnvServerReadHeaderTimeout: "3s"},
+		},
+		{
+			name: "read header longer than read",
+			env:  map[string]string{EnvServerReadTimeout: "3s", EnvServerReadHeaderTimeout: "4s"},
+			err:  `invalid configuration (SERVER_READ_HEADER_TIMEOUT) got="4s" longer than SERVER_READ_TIMEOUT=3s`,
+		},
+		{
+			name:    "shutdown shorter than write",
+			env:     map[string]string{EnvServerWriteTimeout: "30s", EnvServerShutdownTimeout: "20s"},
+			warning: `invalid configuration (SERVER_SHUTDOWN_TIMEOUT) got="20s" shorter than SERVER_WRITE_TIMEOUT=30s`,
+		},
+		{
+			name: "shutdown equal to write",
+			env:  map[string]string{EnvServerWriteTimeout: "30s", EnvServerShutdownTimeout: "30s"},
+		},
+		{
+			name:    "idle shorter than read",
+			env:     map[string]string{EnvServerReadTimeout: "5s", EnvServerIdleTimeout: "4s"},
+			warning: `invalid configuration (SERVER_IDLE_TIMEOUT) got="4s" shorter than SERVER_READ_TIMEOUT=5s`,
+		},
+		{
+			name: "zero disables the rule",
+			env:  map[string]string{EnvServerReadTimeout: "0", EnvServerReadHeaderTimeout: "4s"},
+		},
+		{
+			name: "invalid value suppresses the cascade",
+			env:  map[string]string{EnvServerReadTimeout: "soon", EnvServerReadHeaderTimeout: "9s", EnvServerIdleTimeout: "1s"},
+			err:  `invalid configuration (SERVER_READ_TIMEOUT) got="soon"`,
+		},
+		{
+			name: "prefixed names",
+			env:  map[string]string{EnvPrefix: "APP", "APP_SERVER_READ_TIMEOUT": "3s", "APP_SERVER_READ_HEADER_TIMEOUT": "4s"},
+			err:  `invalid configuration (APP_SERVER_READ_HEADER_TIMEOUT) got="4s" longer than APP_SERVER_READ_TIMEOUT=3s`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(WithLookuper(mapLookup(tt.env)))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("New() error = %v, want one containing %q", err, tt.err)
+				}
+				if strings.Contains(tt.name, "cascade") {
+					// The strict mode promotes the inconsistency warnings too.
+					_, err := New(WithLookuper(mapLookup(tt.env)), Strict(), Optional(envKeys...))
+					if errors.Is(err, ErrInconsistentValues) {
+						t.Errorf("New() error = %v, want no inconsistency reported", err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var want []string
+			if tt.warning != "" {
+				want = []string{tt.warning}
+			}
+			var got []string
+			for _, w := range c.Warnings() {
+				if strings.Contains(w, "than") {
+					got = append(got, w)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("inconsistency warnings = %q, want %q", got, want)
+			}
+		})
+	}
+}
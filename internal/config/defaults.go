@@ -0,0 +1,55 @@
+package config
+
+import "strings"
+
+// Build-time defaults override the Default* constants of the matching variables,
+// as well as the defaults of the [Environment], when set with the linker, e.g.:
+//
+//	go build -ldflags "-X mega/internal/config.DefaultServerAddressStr=:8080"
+//
+// They are parsed and validated by [New] like any other value, so an invalid one
+// is reported as a configuration error naming the variable. Empty values keep
+// the constants in effect.
+var (
+	DefaultLogLevelStr                string
+	DefaultLogFormatStr               string
+	DefaultLogOutputStr               string
+	DefaultServerAddressStr           string
+	DefaultServerReadTimeoutStr       string
+	DefaultServerReadHeaderTimeoutStr string
+	DefaultServerWriteTimeoutStr      string
+	DefaultServerIdleTimeoutStr       string
+	DefaultServerShutdownTimeoutStr   string
+	DefaultServerUnixSocketModeStr    string
+	DefaultServerMaxHeaderBytesStr    string
+)
+
+// buildDefault returns the build-time default of v, if set.
+func buildDefault(v variable) (value, bool) {
+	if v.buildDefault == nil || *v.buildDefault == "" {
+		return value{}, false
+	}
+	return value{raw: *v.buildDefault, name: buildDefaultName(v.envKey), source: SourceDefault}, true
+}
+
+// buildDefaultName returns the name of the build-time default variable of
+// envKey, e.g. DefaultServerAddressStr for SERVER_ADDRESS.
+func buildDefaultName(envKey string) string {
+	var b strings.Builder
+	b.WriteString("Default")
+	for word := range strings.SplitSeq(strings.ToLower(envKey), "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	b.WriteString("Str")
+	return b.String()
+}
+
+// defaultValue returns the build-time default of envKey, or fallback when unset.
+func defaultValue(envKey, fallback string) string {
+	if v, ok := buildDefault(variableFor(envKey)); ok {
+		return v.raw
+	}
+	return fallback
+}
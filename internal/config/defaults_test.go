@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setBuildDefault simulates injecting val into the build-time default *p with
+// the linker, for the duration of the test. Tests calling it must not be
+// parallel.
+func setBuildDefault(t *testing.T, p *string, val string) {
+	t.Helper()
+	prev := *p
+	*p = val
+	t.Cleanup(func() { *p = prev })
+}
+
+func TestBuildDefaults(t *testing.T) {
+	setBuildDefault(t, &DefaultServerAddressStr, "localhost:9000")
+	setBuildDefault(t, &DefaultServerReadTimeoutStr, "7s")
+	setBuildDefault(t, &DefaultLogLevelStr, "warn")
+
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvLogLevel: "error"})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ServerAddress() != "localhost:9000" {
+		t.Errorf("ServerAddress() = %v, want the build-time default localhost:9000", c.ServerAddress())
+	}
+	if c.ServerReadTimeout() != 7*time.Second {
+		t.Errorf("ServerReadTimeout() = %v, want the build-time default 7s", c.ServerReadTimeout())
+	}
+	if c.LogLevel() != LogLevelError {
+		t.Errorf("LogLevel() = %v, want the environment value %v", c.LogLevel(), LogLevelError)
+	}
+	if c.ServerWriteTimeout() != DefaultServerWriteTimeout {
+		t.Errorf("ServerWriteTimeout() = %v, want the constant %v", c.ServerWriteTimeout(), DefaultServerWriteTimeout)
+	}
+	if c.Source(EnvServerAddress) != SourceDefault {
+		t.Errorf("Source(%s) = %v, want %v", EnvServerAddress, c.Source(EnvServerAddress), SourceDefault)
+	}
+	if f := newFlagSet().Lookup("server-address"); f.DefValue != "localhost:9000" {
+		t.Errorf("flag -server-address default = %q, want the build-time default", f.DefValue)
+	}
+}
+
+func TestBuildDefaultsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *string
+		val  string
+		want error
+	}{
+		{"DefaultServerReadTimeoutStr", &DefaultServerReadTimeoutStr, "soon", ErrInvalidDuration},
+		{"DefaultServerIdleTimeoutStr", &DefaultServerIdleTimeoutStr, "-5s", ErrOutOfRange},
+		{"DefaultLogLevelStr", &DefaultLogLevelStr, "loud", ErrInvalidLogLevel},
+		{"DefaultServerAddressStr", &DefaultServerAddressStr, "nowhere", ErrInvalidAddress},
+		{"DefaultServerUnixSocketModeStr", &DefaultServerUnixSocketModeStr, "0999", ErrInvalidFileMode},
+		{"DefaultServerMaxHeaderBytesStr", &DefaultServerMaxHeaderBytesStr, "lots", ErrInvalidByteSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBuildDefault(t, tt.p, tt.val)
+			_, err := New(WithLookuper(mapLookup(nil)))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("New() error = %v, want %v", err, tt.want)
+			}
+			if want := "(" + tt.name + ") got=\"" + tt.val + "\""; !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		})
+	}
+}
+
+func TestBuildDefaultsCoverFields(t *testing.T) {
+	for _, v := range registry {
+		if v.load == nil {
+			continue
+		}
+		if v.buildDefault == nil {
+			t.Errorf("%s backs a Config field but declares no build-time default", v.envKey)
+			continue
+		}
+		setBuildDefault(t, v.buildDefault, v.fallback)
+		if got, ok := buildDefault(v); !ok || got.raw != v.fallback {
+			t.Errorf("buildDefault(%s) = %q, %v, want %q", v.envKey, got.raw, ok, v.fallback)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"slices"
+)
+
+type (
+	// FieldChange represents a configuration field whose value differs between two
+	// [Config] instances. Field is the Env* constant backing the field, and Old
+	// and New are the string forms of its values, redacted for sensitive fields.
+	FieldChange struct {
+		Field string
+		Old   string
+		New   string
+	}
+
+	// Diff represents the changed fields between two [Config] instances, in
+	// declaration order.
+	Diff []FieldChange
+)
+
+// redacted replaces the values of sensitive fields in rendered forms.
+const redacted = "***"
+
+type (
+	field struct {
+		envKey    string
+		value     string
+		sensitive bool
+	}
+)
+
+// Diff returns the fields whose values differ between c and other, with the
+// values of c as Old and the values of other as New.
+func (c *Config) Diff(other *Config) Diff {
+	var (
+		oldFields = c.fields()
+		newFields = other.fields()
+		diff      Diff
+	)
+	for i, o := range oldFields {
+		n := newFields[i]
+		if o.value == n.value {
+			continue
+		}
+		change := FieldChange{Field: o.envKey, Old: o.value, New: n.value}
+		if o.sensitive {
+			change.Old, change.New = redacted, redacted
+		}
+		diff = append(diff, change)
+	}
+	return diff
+}
+
+// Has reports whether the field backed by envKey (one of the Env* constants)
+// changed.
+func (d Diff) Has(envKey string) bool {
+	return slices.ContainsFunc(d, func(fc FieldChange) bool {
+		return fc.Field == envKey
+	})
+}
+
+// fields returns the fields of c in declaration order. A nil c yields the zero
+// values.
+func (c *Config) fields() []field {
+	if c == nil {
+		c = &Config{}
+	}
+	var fields []field
+	for _, v := range registry {
+		if v.value != nil {
+			fields = append(fields, field{envKey: v.envKey, value: v.value(c), sensitive: v.sensitive})
+		}
+	}
+	return fields
+}
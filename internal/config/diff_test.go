@@ -0,0 +1,59 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+// markSensitive flags the variable backed by envKey as sensitive for the
+// duration of the test. Tests calling it must not be parallel.
+func markSensitive(t *testing.T, envKey string) {
+	t.Helper()
+	idx := slices.IndexFunc(registry, func(v variable) bool {
+		return v.envKey == envKey
+	})
+	registry[idx].sensitive = true
+	t.Cleanup(func() { registry[idx].sensitive = false })
+}
+
+func TestConfigDiff(t *testing.T) {
+	a, err := NewFromMap(map[string]string{EnvLogLevel: "info", EnvServerAddress: ":8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFromMap(map[string]string{EnvLogLevel: "debug", EnvServerAddress: ":9090", EnvServerIdleTimeout: "90s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Diff{
+		{Field: EnvLogLevel, Old: "info", New: "debug"},
+		{Field: EnvServerAddress, Old: ":8080", New: ":9090"},
+		{Field: EnvServerIdleTimeout, Old: DefaultServerIdleTimeout.String(), New: "1m30s"},
+	}
+	got := a.Diff(b)
+	if !slices.Equal(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+	if !got.Has(EnvServerAddress) || got.Has(EnvLogFormat) {
+		t.Errorf("Has() reports the wrong fields for %v", got)
+	}
+	if diff := a.Diff(a); len(diff) > 0 {
+		t.Errorf("Diff() with itself = %v, want none", diff)
+	}
+}
+
+func TestConfigDiffRedacted(t *testing.T) {
+	markSensitive(t, EnvServerAddress)
+	a, err := NewFromMap(map[string]string{EnvServerAddress: ":8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFromMap(map[string]string{EnvServerAddress: ":9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Diff{{Field: EnvServerAddress, Old: redacted, New: redacted}}
+	if got := a.Diff(b); !slices.Equal(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// loadDir reads the configuration directory, where each regular file holds the
+// value of the variable it is named after. Dotfiles (such as the "..data"
+// symlink of Kubernetes projected volumes) and subdirectories are skipped, and a
+// single trailing newline is stripped from each value. Files named after no
+// known variable are reported as warnings.
+func (l *loader) loadDir() {
+	if l.ctx.Err() != nil {
+		return
+	}
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		l.addError(&ParseError{Var: l.dir, Err: err, kind: ErrInvalidFile})
+		return
+	}
+	known := append(EnvNames(l.prefix), envKeys...)
+	l.dirFiles = make(map[string]string)
+	for _, e := range entries {
+		if l.ctx.Err() != nil {
+			return
+		}
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(l.dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			l.addError(&ParseError{Var: path, Err: err, kind: ErrInvalidFile})
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if !slices.Contains(known, name) {
+			l.addWarning(&ValidationError{Var: path, Reason: "unknown variable" + suggest(name, known), kind: ErrUnknownVariable})
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			l.addError(&ParseError{Var: path, Err: err, kind: ErrInvalidFile})
+			continue
+		}
+		val := strings.TrimSuffix(string(b), "\n")
+		l.dirFiles[name] = strings.TrimSuffix(val, "\r")
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// projectedVolume builds a directory laid out like a Kubernetes projected
+// volume: the files live in a timestamped directory, linked by the "..data"
+// symlink, and each key is a symlink through "..data".
+func projectedVolume(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	ts := filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+	if err := os.Mkdir(ts, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(ts, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Base(ts), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDirSource(t *testing.T) {
+	dir := projectedVolume(t, map[string]string{
+		"LOG_LEVEL":      "debug\n",
+		"LOG_FORMAT":     "json",
+		"SERVER_ADDRESS": ":9000\r\n",
+		"LOG_OUTPUT":     "/var/log/app.log\n\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "SERVER_READ_TIMEOUT"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".SERVER_WRITE_TIMEOUT"), []byte("1s"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvLogFormat: "text"})), WithDirSource(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelDebug {
+		t.Errorf("LogLevel() = %v, want the directory value %v", c.LogLevel(), LogLevelDebug)
+	}
+	if c.LogFormat() != LogFormatText {
+		t.Errorf("LogFormat() = %v, want the environment value %v", c.LogFormat(), LogFormatText)
+	}
+	if c.ServerAddress() != ":9000" {
+		t.Errorf("ServerAddress() = %v, want :9000", c.ServerAddress())
+	}
+	if c.LogOutput() != "/var/log/app.log" {
+		t.Errorf("LogOutput() = %q, want a single trailing newline stripped", c.LogOutput())
+	}
+	if c.ServerReadTimeout() != DefaultServerReadTimeout || c.ServerWriteTimeout() != DefaultServerWriteTimeout {
+		t.Errorf("subdirectories and dotfiles were read: got %v and %v", c.ServerReadTimeout(), c.ServerWriteTimeout())
+	}
+	if c.Source(EnvLogLevel) != SourceFile {
+		t.Errorf("Source(%s) = %v, want %v", EnvLogLevel, c.Source(EnvLogLevel), SourceFile)
+	}
+	if w := c.Warnings(); len(w) > 0 {
+		t.Errorf("Warnings() = %q, want none", w)
+	}
+}
+
+func TestDirSourceUnknownFiles(t *testing.T) {
+	dir := projectedVolume(t, map[string]string{"LOG_LEVL": "debug", "README": "hello"})
+	c, err := New(WithLookuper(mapLookup(nil)), WithDirSource(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := []string{
+		"invalid configuration (" + filepath.Join(dir, "LOG_LEVL") + ") unknown variable, did you mean LOG_LEVEL?",
+		"invalid configuration (" + filepath.Join(dir, "README") + ") unknown variable",
+	}
+	if got := c.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+}
+
+func TestDirSourceVariable(t *testing.T) {
+	dir := projectedVolume(t, map[string]string{"LOG_LEVEL": "warn"})
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvConfigDir: dir})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelWarn {
+		t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), LogLevelWarn)
+	}
+	if _, err := New(WithLookuper(mapLookup(map[string]string{EnvConfigDir: filepath.Join(dir, "missing")}))); err == nil {
+		t.Error("New() error = nil, want an error for a missing directory")
+	}
+}
+
+func TestDirSourceFromEnvFile(t *testing.T) {
+	setenv(t, nil)
+	dir := projectedVolume(t, map[string]string{"LOG_LEVEL": "error"})
+	writeFile(t, ".env", EnvConfigDir+"="+dir+"\n")
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelError {
+		t.Errorf("LogLevel() = %v, want the directory value %v", c.LogLevel(), LogLevelError)
+	}
+}
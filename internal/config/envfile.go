@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// parseEnvFile parses "KEY=VALUE" lines from r.
+//
+// Blank lines and lines starting with "#" are skipped, an optional "export"
+// prefix is accepted, and values may be wrapped in single quotes (taken
+// literally) or double quotes (supporting \n, \r, \t, \" and \\ escapes).
+// Unquoted values end at an inline " #" comment. Later entries override
+// earlier ones. Malformed lines are reported with their line number and do not
+// stop the parsing of the remaining lines.
+func parseEnvFile(r io.Reader) (map[string]string, error) {
+	var (
+		vals = make(map[string]string)
+		errs []error
+	)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		key, val, ok, err := parseEnvLine(sc.Text())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", n, err))
+			continue
+		}
+		if ok {
+			vals[key] = val
+		}
+	}
+	if err := sc.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return vals, errors.Join(errs...)
+}
+
+func parseEnvLine(line string) (key, val string, ok bool, err error) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	if rest, found := strings.CutPrefix(line, "export"); found && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		line = strings.TrimSpace(rest)
+	}
+	key, val, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("missing '=' in %q", line)
+	}
+	key = strings.TrimSpace(key)
+	if !isEnvKey(key) {
+		return "", "", false, fmt.Errorf("invalid key %q", key)
+	}
+	val, err = parseEnvValue(strings.TrimSpace(val))
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return key, val, true, nil
+}
+
+func parseEnvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return s[1 : end+1], trailingComment(s[end+2:])
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"':
+				return b.String(), trailingComment(s[i+1:])
+			case '\\':
+				if i++; i == len(s) {
+					return "", errors.New("unterminated double quote")
+				}
+				switch e := s[i]; e {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(e)
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double quote")
+	}
+	if idx := strings.Index(s, " #"); idx >= 0 {
+		s = s[:idx]
+	}
+	if idx := strings.Index(s, "\t#"); idx >= 0 {
+		s = s[:idx]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+func trailingComment(s string) error {
+	if s = strings.TrimSpace(s); s != "" && !strings.HasPrefix(s, "#") {
+		return fmt.Errorf("unexpected characters %q after closing quote", s)
+	}
+	return nil
+}
+
+func isEnvKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{"plain", "LOG_LEVEL=debug\n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"comments and blank lines", "# comment\n\n  # indented\nLOG_LEVEL=debug\n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"export prefix", "export LOG_LEVEL=debug\n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"surrounding spaces", "  LOG_LEVEL =  debug  \n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"empty value", "LOG_LEVEL=\n", map[string]string{"LOG_LEVEL": ""}},
+		{"inline comment", "LOG_LEVEL=debug # verbose\n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"hash without space", "SERVER_ADDRESS=a#b\n", map[string]string{"SERVER_ADDRESS": "a#b"}},
+		{"single quotes", "LOG_OUTPUT='/var/log/a b.log'\n", map[string]string{"LOG_OUTPUT": "/var/log/a b.log"}},
+		{"single quotes are literal", `LOG_OUTPUT='a\nb'` + "\n", map[string]string{"LOG_OUTPUT": `a\nb`}},
+		{"double quotes", `LOG_OUTPUT="a # b"` + "\n", map[string]string{"LOG_OUTPUT": "a # b"}},
+		{"double quote escapes", `LOG_OUTPUT="a\tb\n\"c\"\\"` + "\n", map[string]string{"LOG_OUTPUT": "a\tb\n\"c\"\\"}},
+		{"quoted value with comment", `LOG_LEVEL="debug" # verbose` + "\n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"CRLF", "LOG_LEVEL=debug\r\nLOG_FORMAT=\"json\"\r\n", map[string]string{"LOG_LEVEL": "debug", "LOG_FORMAT": "json"}},
+		{"BOM", "\ufeffLOG_LEVEL=debug\n", map[string]string{"LOG_LEVEL": "debug"}},
+		{"later entries win", "LOG_LEVEL=debug\nLOG_LEVEL=warn\n", map[string]string{"LOG_LEVEL": "warn"}},
+		{"no trailing newline", "LOG_LEVEL=debug", map[string]string{"LOG_LEVEL": "debug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvFile(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatalf("parseEnvFile() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseEnvFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	in := "LOG_LEVEL=debug\nnot a pair\nLOG_FORMAT='json\n1BAD=x\nLOG_OUTPUT=\"a\" b\nSERVER_ADDRESS=:80\n"
+	got, err := parseEnvFile(strings.NewReader(in))
+	if err == nil {
+		t.Fatal("parseEnvFile() error = nil, want an error")
+	}
+	for _, want := range []string{"line 2:", "line 3:", "line 4:", "line 5:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	want := map[string]string{"LOG_LEVEL": "debug", "SERVER_ADDRESS": ":80"}
+	if !maps.Equal(got, want) {
+		t.Errorf("parseEnvFile() = %v, want the valid lines %v", got, want)
+	}
+}
+
+func TestNewEnvFile(t *testing.T) {
+	t.Run("environment wins", func(t *testing.T) {
+		setenv(t, map[string]string{EnvLogLevel: "warn"})
+		writeFile(t, ".env", "LOG_LEVEL=debug\nLOG_FORMAT=json\n")
+		c, err := New()
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if c.LogLevel() != LogLevelWarn {
+			t.Errorf("LogLevel() = %v, want the environment value %v", c.LogLevel(), LogLevelWarn)
+		}
+		if c.LogFormat() != LogFormatJSON {
+			t.Errorf("LogFormat() = %v, want the env file value %v", c.LogFormat(), LogFormatJSON)
+		}
+	})
+	t.Run("explicit path", func(t *testing.T) {
+		setenv(t, map[string]string{EnvConfigEnvFile: "app.env"})
+		writeFile(t, "app.env", "LOG_LEVEL=error\r\n")
+		writeFile(t, ".env", "LOG_LEVEL=debug\n")
+		c, err := New()
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if c.LogLevel() != LogLevelError {
+			t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), LogLevelError)
+		}
+	})
+	t.Run("missing implicit file", func(t *testing.T) {
+		setenv(t, nil)
+		if _, err := New(); err != nil {
+			t.Errorf("New() error = %v, want nil", err)
+		}
+	})
+	t.Run("missing explicit file", func(t *testing.T) {
+		setenv(t, map[string]string{EnvConfigEnvFile: "missing.env"})
+		_, err := New()
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("New() error = %v, want %v", err, fs.ErrNotExist)
+		}
+	})
+	t.Run("malformed line", func(t *testing.T) {
+		setenv(t, nil)
+		writeFile(t, ".env", "LOG_LEVEL=debug\noops\n")
+		_, err := New()
+		if err == nil || !strings.Contains(err.Error(), "line 2:") {
+			t.Errorf("New() error = %v, want a line-numbered error", err)
+		}
+	})
+}
+
+// writeFile writes content to the file at path, failing the test on error.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
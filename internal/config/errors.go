@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors wrapped by the [*ParseError] and [*ValidationError] failures
+// of the loader, so that their category can be tested with [errors.Is] on the
+// error returned by [New].
+var (
+	// ErrInvalidLogLevel reports a value of [EnvLogLevel] that is not a [LogLevel].
+	ErrInvalidLogLevel = errors.New("invalid log level")
+
+	// ErrInvalidLogFormat reports a value of [EnvLogFormat] that is not a
+	// [LogFormat].
+	ErrInvalidLogFormat = errors.New("invalid log format")
+
+	// ErrInvalidEnvironment reports a value of [EnvAppEnv] that is not an
+	// [Environment].
+	ErrInvalidEnvironment = errors.New("invalid environment")
+
+	// ErrInvalidDuration reports a value that is not a valid duration.
+	ErrInvalidDuration = errors.New("invalid duration")
+
+	// ErrInvalidBool reports a value that is not a valid boolean.
+	ErrInvalidBool = errors.New("invalid bool")
+
+	// ErrInvalidNumber reports a value that is not a valid number.
+	ErrInvalidNumber = errors.New("invalid number")
+
+	// ErrOutOfRange reports a value outside of the range accepted by its variable.
+	ErrOutOfRange = errors.New("value out of range")
+
+	// ErrInconsistentValues reports values of related variables contradicting
+	// each other (e.g., a read header timeout longer than the read timeout).
+	ErrInconsistentValues = errors.New("inconsistent values")
+
+	// ErrInvalidAddress reports a value of [EnvServerAddress] that is not a valid
+	// address.
+	ErrInvalidAddress = errors.New("invalid address")
+
+	// ErrInvalidFileMode reports a value that is not a valid octal file mode.
+	ErrInvalidFileMode = errors.New("invalid file mode")
+
+	// ErrInvalidByteSize reports a value that is not a valid byte size (see
+	// [ParseByteSize]).
+	ErrInvalidByteSize = errors.New("invalid byte size")
+
+	// ErrDuplicateAddress reports a server's address listed more than once.
+	ErrDuplicateAddress = errors.New("duplicate address")
+
+	// ErrInvalidFile reports a configuration file, env file, configuration
+	// directory or "_FILE" variable file that cannot be read or decoded.
+	ErrInvalidFile = errors.New("invalid file")
+
+	// ErrProvider reports a [Provider] that failed to load its values.
+	ErrProvider = errors.New("provider failure")
+
+	// ErrMissingRequired reports a variable left unset in the strict mode, or an
+	// override option (e.g., [WithLogOutput]) given a blank value.
+	ErrMissingRequired = errors.New("missing required variable")
+
+	// ErrUnknownVariable reports a variable, key or file naming no known
+	// configuration variable.
+	ErrUnknownVariable = errors.New("unknown variable")
+
+	// ErrConflictingVariables reports a variable set along with its "_FILE"
+	// sibling.
+	ErrConflictingVariables = errors.New("conflicting variables")
+)
+
+type (
+	// ParseError represents a configuration value that could not be parsed. Use
+	// [errors.As] on the error returned by [New] to inspect every failure.
+	ParseError struct {
+		// Var specifies the name under which the value was found: the variable,
+		// flag, configuration file key or option name, or the file, directory or
+		// provider that failed as a whole.
+		Var string
+
+		// Value specifies the offending value, redacted for sensitive variables,
+		// or an empty string when the failure concerns a whole source (e.g., a
+		// malformed configuration file named by Var).
+		Value string
+
+		// Err specifies the cause of the failure.
+		Err error
+
+		kind error
+	}
+
+	// ValidationError represents a configuration value, or a missing one, that
+	// does not satisfy a constraint. Use [errors.As] on the error returned by [New]
+	// to inspect every failure.
+	ValidationError struct {
+		// Var specifies the name under which the value was found: the variable,
+		// flag, configuration file key or option name.
+		Var string
+
+		// Value specifies the offending value, redacted for sensitive variables,
+		// or an empty string when the failure concerns no value.
+		Value string
+
+		// Reason specifies the violated constraint.
+		Reason string
+
+		kind error
+	}
+)
+
+func (e *ParseError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid configuration (%s): %v", e.Var, e.Err)
+	}
+	return fmt.Sprintf("invalid configuration (%s) got=%q: %v", e.Var, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error of the category of e.
+func (e *ParseError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+func (e *ValidationError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid configuration (%s) %s", e.Var, e.Reason)
+	}
+	return fmt.Sprintf("invalid configuration (%s) got=%q %s", e.Var, e.Value, e.Reason)
+}
+
+// Is reports whether target is the sentinel error of the category of e.
+func (e *ValidationError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// failures returns the [*ParseError] and [*ValidationError] leaves of the error
+// tree of err, in order.
+func failures(err error) []error {
+	var pe *ParseError
+	var ve *ValidationError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &pe) && error(pe) == err, errors.As(err, &ve) && error(ve) == err:
+		return []error{err}
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, e := range u.Unwrap() {
+			errs = append(errs, failures(e)...)
+		}
+		return errs
+	case interface{ Unwrap() error }:
+		return failures(u.Unwrap())
+	}
+	return nil
+}
+
+func TestErrorTypes(t *testing.T) {
+	env := map[string]string{
+		EnvLogLevel:                "loud",
+		EnvServerReadTimeout:       "soon",
+		EnvServerIdleTimeout:       "-1s",
+		EnvServerReadHeaderTimeout: "9s",
+	}
+	_, err := New(WithLookuper(mapLookup(env)))
+	if err == nil {
+		t.Fatal("New() error = nil, want an error")
+	}
+	type failure struct{ kind, name, value string }
+	var got []failure
+	for _, e := range failures(err) {
+		var pe *ParseError
+		var ve *ValidationError
+		switch {
+		case errors.As(e, &pe):
+			got = append(got, failure{"parse", pe.Var, pe.Value})
+		case errors.As(e, &ve):
+			got = append(got, failure{"validation", ve.Var, ve.Value})
+		}
+	}
+	want := []failure{
+		{"validation", EnvLogLevel, "loud"},
+		{"parse", EnvServerReadTimeout, "soon"},
+		{"validation", EnvServerIdleTimeout, "-1s"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("failures = %v, want %v", got, want)
+	}
+
+	for range 10 {
+		if _, again := New(WithLookuper(mapLookup(env))); again.Error() != err.Error() {
+			t.Fatalf("error order is not deterministic: %q and %q", again, err)
+		}
+	}
+}
+
+func TestErrorTypesAcrossSources(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "level"), "loud")
+	writeFile(t, filepath.Join(dir, "config.yaml"), "log:\n  format: xml\n")
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"variable file", []Option{WithLookuper(mapLookup(map[string]string{EnvLogLevel + "_FILE": filepath.Join(dir, "level")}))}, EnvLogLevel + "_FILE"},
+		{"missing variable file", []Option{WithLookuper(mapLookup(map[string]string{EnvLogLevel + "_FILE": filepath.Join(dir, "none")}))}, EnvLogLevel + "_FILE"},
+		{"config file", []Option{WithLookuper(mapLookup(map[string]string{EnvConfigFile: filepath.Join(dir, "config.yaml")}))}, "log.format"},
+		{"missing config file", []Option{WithLookuper(mapLookup(map[string]string{EnvConfigFile: filepath.Join(dir, "none.yaml")}))}, filepath.Join(dir, "none.yaml")},
+		{"missing env file", []Option{WithLookuper(mapLookup(map[string]string{EnvConfigEnvFile: filepath.Join(dir, "none.env")}))}, EnvConfigEnvFile},
+		{"missing directory", []Option{WithLookuper(mapLookup(nil)), WithDirSource(filepath.Join(dir, "none"))}, filepath.Join(dir, "none")},
+		{"provider", []Option{WithLookuper(mapLookup(nil)), WithProviders(StaticProvider{EnvLogLevel: "loud"})}, EnvLogLevel + " from static"},
+		{"override", []Option{WithLookuper(mapLookup(nil)), WithLogLevel("loud")}, "WithLogLevel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts...)
+			var pe *ParseError
+			var ve *ValidationError
+			switch {
+			case errors.As(err, &pe):
+				if pe.Var != tt.want {
+					t.Errorf("ParseError.Var = %q, want %q", pe.Var, tt.want)
+				}
+			case errors.As(err, &ve):
+				if ve.Var != tt.want {
+					t.Errorf("ValidationError.Var = %q, want %q", ve.Var, tt.want)
+				}
+			default:
+				t.Errorf("New() error = %v, want a ParseError or ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestErrorRedacted(t *testing.T) {
+	markSensitive(t, EnvServerAddress)
+	_, err := New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: "secret-host"})))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("New() error = %v, want a ParseError", err)
+	}
+	if pe.Value != redacted || strings.Contains(err.Error(), "secret-host") {
+		t.Errorf("New() error = %v, want the value redacted", err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	tests := map[string]struct {
+		value string
+		want  error
+	}{
+		EnvAppEnv:                  {"prod", ErrInvalidEnvironment},
+		EnvLogLevel:                {"loud", ErrInvalidLogLevel},
+		EnvLogFormat:               {"xml", ErrInvalidLogFormat},
+		EnvServerAddress:           {"nowhere", ErrInvalidAddress},
+		EnvServerReadTimeout:       {"soon", ErrInvalidDuration},
+		EnvServerReadHeaderTimeout: {"-1s", ErrOutOfRange},
+		EnvServerWriteTimeout:      {"soon", ErrInvalidDuration},
+		EnvServerIdleTimeout:       {"soon", ErrInvalidDuration},
+		EnvServerShutdownTimeout:   {"soon", ErrInvalidDuration},
+		EnvServerUnixSocketMode:    {"0999", ErrInvalidFileMode},
+		EnvServerMaxHeaderBytes:    {"lots", ErrInvalidByteSize},
+		EnvConfigStrict:            {"maybe", ErrInvalidBool},
+		EnvConfigWatchInterval:     {"soon", ErrInvalidDuration},
+		EnvConfigFile:              {missing, ErrInvalidFile},
+		EnvConfigEnvFile:           {missing, ErrInvalidFile},
+		EnvConfigDir:               {missing, ErrInvalidFile},
+	}
+	// LOG_OUTPUT accepts any file path and ENV_PREFIX any prefix.
+	for _, v := range registry {
+		if _, ok := tests[v.envKey]; !ok && v.envKey != EnvLogOutput && v.envKey != EnvPrefix {
+			t.Errorf("no invalid value tested for %s", v.envKey)
+		}
+	}
+	for envKey, tt := range tests {
+		t.Run(envKey, func(t *testing.T) {
+			t.Parallel()
+			_, err := New(WithLookuper(mapLookup(map[string]string{envKey: tt.value})))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("New() with %s=%q error = %v, want %v", envKey, tt.value, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentinelErrorsJoined(t *testing.T) {
+	_, err := New(WithLookuper(mapLookup(map[string]string{
+		EnvLogLevel:                "loud",
+		EnvServerWriteTimeout:      "soon",
+		EnvServerReadTimeout:       "1s",
+		EnvServerReadHeaderTimeout: "2s",
+	})))
+	for _, want := range []error{ErrInvalidLogLevel, ErrInvalidDuration} {
+		if !errors.Is(err, want) {
+			t.Errorf("New() error = %v, want %v", err, want)
+		}
+	}
+	if errors.Is(err, ErrInvalidLogFormat) {
+		t.Errorf("New() error = %v, want no %v", err, ErrInvalidLogFormat)
+	}
+
+	_, err = New(WithLookuper(mapLookup(map[string]string{EnvServerReadTimeout: "1s", EnvServerReadHeaderTimeout: "2s"})))
+	if !errors.Is(err, ErrInconsistentValues) {
+		t.Errorf("New() error = %v, want %v", err, ErrInconsistentValues)
+	}
+	_, err = New(WithLookuper(mapLookup(map[string]string{EnvServerAddress: ":8080,:8080"})), Strict(), Optional(envKeys...))
+	if !errors.Is(err, ErrDuplicateAddress) {
+		t.Errorf("New() in strict mode error = %v, want %v", err, ErrDuplicateAddress)
+	}
+	_, err = New(WithLookuper(mapLookup(nil)), Strict())
+	if !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("New() in strict mode error = %v, want %v", err, ErrMissingRequired)
+	}
+	_, err = New(WithLookuper(mapLookup(map[string]string{EnvLogLevel: "info", EnvLogLevel + "_FILE": "level"})))
+	if !errors.Is(err, ErrConflictingVariables) {
+		t.Errorf("New() error = %v, want %v", err, ErrConflictingVariables)
+	}
+}
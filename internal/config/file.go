@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Format represents the encoding of a configuration file.
+	Format string
+)
+
+const (
+	// FormatYAML decodes configuration files as YAML documents.
+	FormatYAML Format = "yaml"
+
+	// FormatJSON decodes configuration files as JSON objects.
+	FormatJSON Format = "json"
+)
+
+// fileKeys maps the dotted keys of the configuration file to the environment
+// variables they stand for: the variables backing [Config] fields, lower-cased
+// with their first underscore replaced by a dot (e.g., "server.read_timeout"
+// for [EnvServerReadTimeout]).
+var fileKeys = func() map[string]string {
+	keys := make(map[string]string)
+	for _, v := range registry {
+		if v.load != nil {
+			keys[fileKey(v.envKey)] = v.envKey
+		}
+	}
+	return keys
+}()
+
+// fileKey returns the configuration file key standing for envKey.
+func fileKey(envKey string) string {
+	return strings.Replace(strings.ToLower(envKey), "_", ".", 1)
+}
+
+func isFileSection(key string) bool {
+	for k := range fileKeys {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *loader) loadConfigFile(path string) {
+	l.configPath = path
+	if l.ctx.Err() != nil {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		l.addError(&ParseError{Var: path, Err: err, kind: ErrInvalidFile})
+		return
+	}
+	defer f.Close()
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = FormatJSON
+	}
+	l.loadConfigReader(path, f, format)
+}
+
+func (l *loader) loadConfigReader(name string, r io.Reader, format Format) {
+	var (
+		vals map[string]string
+		err  error
+	)
+	switch format {
+	case FormatYAML:
+		vals, err = parseYAMLConfig(r)
+	case FormatJSON:
+		vals, err = parseJSONConfig(r)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		l.addParseErrors(name, err, ErrInvalidFile)
+	}
+	l.configFile = vals
+}
+
+// parseYAMLConfig decodes a YAML configuration document from r into values keyed
+// by environment variable name. Unknown keys and non-scalar values are reported
+// without stopping the decoding of the remaining keys.
+func parseYAMLConfig(r io.Reader) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	var (
+		vals = make(map[string]string)
+		errs []error
+	)
+	var walk func(prefix string, node *yaml.Node)
+	walk = func(prefix string, node *yaml.Node) {
+		if node.Kind == yaml.DocumentNode {
+			for _, n := range node.Content {
+				walk(prefix, n)
+			}
+			return
+		}
+		if node.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("line %d: expected a mapping at the document root", node.Line))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			key := joinFileKey(prefix, k.Value)
+			envKey, known := fileKeys[key]
+			switch {
+			case known && v.Kind == yaml.ScalarNode:
+				if v.Tag != "!!null" {
+					vals[envKey] = v.Value
+				}
+			case known:
+				errs = append(errs, fmt.Errorf("line %d: expected a scalar value for %q", v.Line, key))
+			case v.Kind == yaml.MappingNode && isFileSection(key):
+				walk(key, v)
+			case v.Tag == "!!null" && isFileSection(key):
+				// An empty section, accepted like a null one in JSON.
+			default:
+				errs = append(errs, fmt.Errorf("line %d: unknown key %q", k.Line, key))
+			}
+		}
+	}
+	walk("", &doc)
+	return vals, errors.Join(errs...)
+}
+
+// parseJSONConfig decodes a JSON configuration object from r into values keyed
+// by environment variable name. Durations are either strings (e.g., "5s") or
+// integer numbers of nanoseconds, like [time.Duration] values are encoded. Unknown
+// keys and non-scalar values are reported without stopping the decoding of the
+// remaining keys.
+func parseJSONConfig(r io.Reader) (map[string]string, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	var (
+		vals = make(map[string]string)
+		errs []error
+	)
+	var walk func(prefix string, node any)
+	walk = func(prefix string, node any) {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			errs = append(errs, errors.New("expected an object at the document root"))
+			return
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			key := joinFileKey(prefix, k)
+			envKey, known := fileKeys[key]
+			switch v := obj[k].(type) {
+			case nil:
+				if !known && !isFileSection(key) {
+					errs = append(errs, fmt.Errorf("unknown key %q", key))
+				}
+			case string, json.Number, bool:
+				if !known {
+					errs = append(errs, fmt.Errorf("unknown key %q", key))
+					continue
+				}
+				if n, ok := v.(json.Number); ok && variableFor(envKey).kind == VarTypeDuration {
+					// Numeric durations are nanoseconds, as time.Duration encodes them.
+					ns, err := n.Int64()
+					if err != nil {
+						errs = append(errs, fmt.Errorf("expected a duration string or an integer number of nanoseconds for %q", key))
+						continue
+					}
+					vals[envKey] = time.Duration(ns).String()
+					continue
+				}
+				vals[envKey] = fmt.Sprint(v)
+			case map[string]any:
+				if known {
+					errs = append(errs, fmt.Errorf("expected a scalar value for %q", key))
+					continue
+				}
+				if !isFileSection(key) {
+					errs = append(errs, fmt.Errorf("unknown key %q", key))
+					continue
+				}
+				walk(key, v)
+			default:
+				if known {
+					errs = append(errs, fmt.Errorf("expected a scalar value for %q", key))
+					continue
+				}
+				errs = append(errs, fmt.Errorf("unknown key %q", key))
+			}
+		}
+	}
+	walk("", doc)
+	return vals, errors.Join(errs...)
+}
+
+func joinFileKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
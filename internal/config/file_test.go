@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFromFileYAML(t *testing.T) {
+	setenv(t, map[string]string{EnvServerReadTimeout: "9s"})
+	writeFile(t, "config.yaml", `
+log:
+  level: debug
+server:
+  address: ":8081"
+  read_timeout: 3s
+`)
+	c, err := NewFromFile("config.yaml")
+	if err != nil {
+		t.Fatalf("NewFromFile() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelDebug {
+		t.Errorf("LogLevel() = %v, want the file value %v", c.LogLevel(), LogLevelDebug)
+	}
+	if c.ServerAddress() != ":8081" {
+		t.Errorf("ServerAddress() = %v, want the file value :8081", c.ServerAddress())
+	}
+	if c.ServerReadTimeout() != 9*time.Second {
+		t.Errorf("ServerReadTimeout() = %v, want the environment value 9s", c.ServerReadTimeout())
+	}
+	if c.LogFormat() != DefaultLogFormat {
+		t.Errorf("LogFormat() = %v, want the default %v", c.LogFormat(), DefaultLogFormat)
+	}
+	if c.ServerWriteTimeout() != DefaultServerWriteTimeout {
+		t.Errorf("ServerWriteTimeout() = %v, want the default %v", c.ServerWriteTimeout(), DefaultServerWriteTimeout)
+	}
+}
+
+func TestNewConfigFileVariable(t *testing.T) {
+	setenv(t, map[string]string{EnvConfigFile: "config.yml", EnvLogFormat: "text"})
+	writeFile(t, "config.yml", "log:\n  level: warn\n  format: json\n")
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelWarn {
+		t.Errorf("LogLevel() = %v, want the file value %v", c.LogLevel(), LogLevelWarn)
+	}
+	if c.LogFormat() != LogFormatText {
+		t.Errorf("LogFormat() = %v, want the environment value %v", c.LogFormat(), LogFormatText)
+	}
+}
+
+func TestNewFromReaderYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			"unknown keys",
+			"log:\n  levl: debug\nsrv:\n  address: :80\n",
+			[]string{`line 2: unknown key "log.levl"`, `line 3: unknown key "srv"`},
+		},
+		{
+			"non-scalar value",
+			"log:\n  level:\n    - debug\n",
+			[]string{`line 3: expected a scalar value for "log.level"`},
+		},
+		{
+			"not a mapping",
+			"- debug\n",
+			[]string{"expected a mapping at the document root"},
+		},
+		{
+			"three bad values",
+			"log:\n  level: loud\n  format: xml\nserver:\n  read_timeout: soon\n",
+			[]string{`(log.level) got="loud"`, `(log.format) got="xml"`, `(server.read_timeout) got="soon"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFromReader(strings.NewReader(tt.doc), FormatYAML, WithLookuper(mapLookup(nil)))
+			if err == nil {
+				t.Fatal("NewFromReader() error = nil, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewFromReaderYAMLEmpty(t *testing.T) {
+	for _, doc := range []string{"", "# nothing\n", "log:\nserver: ~\n"} {
+		c, err := NewFromReader(strings.NewReader(doc), FormatYAML, WithLookuper(mapLookup(nil)))
+		if err != nil {
+			t.Fatalf("NewFromReader(%q) error = %v", doc, err)
+		}
+		if c.LogLevel() != DefaultLogLevel {
+			t.Errorf("NewFromReader(%q).LogLevel() = %v, want the default %v", doc, c.LogLevel(), DefaultLogLevel)
+		}
+	}
+}
+
+func TestNewFromReaderJSON(t *testing.T) {
+	doc := `{
+		"log": {"level": "debug", "format": "json", "output": "stderr"},
+		"server": {
+			"address": ":8081",
+			"read_timeout": "1s",
+			"read_header_timeout": "500ms",
+			"write_timeout": 2000000000,
+			"idle_timeout": "3s",
+			"shutdown_timeout": "4s"
+		}
+	}`
+	c, err := NewFromReader(strings.NewReader(doc), FormatJSON, WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewFromReader() error = %v", err)
+	}
+	got := []any{
+		c.LogLevel(), c.LogFormat(), c.LogOutput(), c.ServerAddress(),
+		c.ServerReadTimeout(), c.ServerReadHeaderTimeout(), c.ServerWriteTimeout(), c.ServerIdleTimeout(), c.ServerShutdownTimeout(),
+	}
+	want := []any{
+		LogLevelDebug, LogFormatJSON, LogOutputStderr, ":8081",
+		time.Second, 500 * time.Millisecond, 2 * time.Second, 3 * time.Second, 4 * time.Second,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNewFromReaderJSONRoundTrip(t *testing.T) {
+	c, err := NewFromMap(map[string]string{
+		EnvLogLevel:           "warn",
+		EnvLogFormat:          "json",
+		EnvServerAddress:      "127.0.0.1:9000",
+		EnvServerWriteTimeout: "45s",
+	})
+	if err != nil {
+		t.Fatalf("NewFromMap() error = %v", err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	// The "app" section reports the environment, which files cannot set.
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	delete(doc, "app")
+	if b, err = json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewFromReader(bytes.NewReader(b), FormatJSON, WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewFromReader(%s) error = %v", b, err)
+	}
+	if diff := c.Diff(got); len(diff) > 0 {
+		t.Errorf("round trip changed %v", diff)
+	}
+}
+
+func TestNewFromReaderJSONPartial(t *testing.T) {
+	c, err := NewFromReader(strings.NewReader(`{"server": {"address": ":7000"}}`), FormatJSON, WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewFromReader() error = %v", err)
+	}
+	defaults, err := NewFromMap(map[string]string{EnvServerAddress: ":7000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := defaults.Diff(c); len(diff) > 0 {
+		t.Errorf("fields other than server.address departed from the defaults: %v", diff)
+	}
+}
+
+func TestNewFromReaderJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"invalid duration", `{"server": {"read_timeout": "soon"}}`, []string{`(server.read_timeout) got="soon"`}},
+		{"boolean duration", `{"server": {"idle_timeout": true}}`, []string{`(server.idle_timeout) got="true"`}},
+		{"fractional nanoseconds", `{"server": {"idle_timeout": 1.5}}`, []string{`integer number of nanoseconds for "server.idle_timeout"`}},
+		{"unknown keys", `{"log": {"levl": "debug"}, "srv": {}}`, []string{`unknown key "log.levl"`, `unknown key "srv"`}},
+		{"non-scalar value", `{"log": {"level": ["debug"]}}`, []string{`expected a scalar value for "log.level"`}},
+		{"not an object", `["debug"]`, []string{"expected an object at the document root"}},
+		{"malformed", `{"log": `, []string{"unexpected EOF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFromReader(strings.NewReader(tt.doc), FormatJSON, WithLookuper(mapLookup(nil)))
+			if err == nil {
+				t.Fatal("NewFromReader() error = nil, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewFromReaderJSONNull(t *testing.T) {
+	c, err := NewFromReader(strings.NewReader(`{"log": {"level": null}, "server": null}`), FormatJSON, WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewFromReader() error = %v", err)
+	}
+	if c.LogLevel() != DefaultLogLevel {
+		t.Errorf("LogLevel() = %v, want the default %v", c.LogLevel(), DefaultLogLevel)
+	}
+}
+
+func TestNewConfigFileJSONExtension(t *testing.T) {
+	setenv(t, map[string]string{EnvConfigFile: "config.JSON"})
+	writeFile(t, "config.JSON", `{"log": {"level": "error"}}`)
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelError {
+		t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), LogLevelError)
+	}
+}
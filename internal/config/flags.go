@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	// flagValue implements [flag.Value] for a configuration variable, keeping the
+	// raw value so that it goes through the same parsing as the environment.
+	flagValue struct {
+		envKey   string
+		value    string
+		validate func(string) error
+	}
+)
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	if v.validate != nil {
+		if err := v.validate(s); err != nil {
+			return err
+		}
+	}
+	v.value = s
+	return nil
+}
+
+// BindFlags registers a command-line flag on fs for every configuration
+// variable, using the defaults as flag defaults. Flag names are the lower-case,
+// dash-separated forms of the environment variable names (e.g.,
+// "-server-address" for [EnvServerAddress]).
+//
+// Enum, duration and byte size flags are validated when the command line is
+// parsed. Use [NewWithFlags] after parsing fs to apply the flags that were set.
+func BindFlags(fs *flag.FlagSet) {
+	for _, v := range registry {
+		if v.load == nil {
+			continue
+		}
+		switch v.kind {
+		case VarTypeEnum:
+			bindEnum(fs, v.envKey, v.fallback, v.description, v.values)
+		case VarTypeDuration:
+			bindDuration(fs, v.envKey, v.fallbackDuration(), v.description)
+		case VarTypeByteSize:
+			bindByteSize(fs, v.envKey, v.fallback, v.description+": "+v.format)
+		default:
+			bindString(fs, v.envKey, v.fallback, v.description+": "+v.format)
+		}
+	}
+}
+
+// NewWithFlags creates and returns a new [Config] instance like [New], with the
+// flags registered on fs by [BindFlags] taking precedence over every other
+// source. Only flags that were explicitly set on the command line are applied.
+func NewWithFlags(fs *flag.FlagSet, opts ...Option) (*Config, error) {
+	l := newLoader(context.Background(), opts)
+	l.loadFlags(fs)
+	if v, ok := l.lookup(EnvConfigFile); ok {
+		l.loadConfigFile(v.raw)
+	}
+	return l.load()
+}
+
+func (l *loader) loadFlags(fs *flag.FlagSet) {
+	l.flags = make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		if v, ok := f.Value.(*flagValue); ok {
+			l.flags[v.envKey] = v.value
+		}
+	})
+}
+
+func bindString(fs *flag.FlagSet, envKey, fallback, usage string) {
+	fallback = defaultValue(envKey, fallback)
+	fs.Var(&flagValue{envKey: envKey, value: fallback}, flagName(envKey), usage)
+}
+
+func bindEnum(fs *flag.FlagSet, envKey, fallback, usage string, allowed []string) {
+	validate := func(s string) error {
+		if _, ok := canonical(s, allowed); ok {
+			return nil
+		}
+		return fmt.Errorf("allowed=%v", allowed)
+	}
+	usage = fmt.Sprintf("%s (%s)", usage, strings.Join(allowed, ", "))
+	fallback = defaultValue(envKey, fallback)
+	fs.Var(&flagValue{envKey: envKey, value: fallback, validate: validate}, flagName(envKey), usage)
+}
+
+func bindDuration(fs *flag.FlagSet, envKey string, fallback time.Duration, usage string) {
+	validate := func(s string) error {
+		_, err := parseDuration(s)
+		return err
+	}
+	fs.Var(&flagValue{envKey: envKey, value: defaultValue(envKey, fallback.String()), validate: validate}, flagName(envKey), usage)
+}
+
+func bindByteSize(fs *flag.FlagSet, envKey, fallback, usage string) {
+	validate := func(s string) error {
+		_, err := ParseByteSize(s)
+		return err
+	}
+	fs.Var(&flagValue{envKey: envKey, value: defaultValue(envKey, fallback), validate: validate}, flagName(envKey), usage)
+}
+
+// flagName returns the command-line flag name for envKey.
+func flagName(envKey string) string {
+	return strings.ReplaceAll(strings.ToLower(envKey), "_", "-")
+}
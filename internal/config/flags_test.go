@@ -0,0 +1,97 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+)
+
+// newFlagSet returns a flag set with the configuration flags bound, silenced so
+// that parse errors only reach the test.
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	BindFlags(fs)
+	return fs
+}
+
+func TestNewWithFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want LogLevel
+		addr string
+	}{
+		{"neither", nil, nil, DefaultLogLevel, DefaultServerAddress},
+		{"flag only", nil, []string{"-log-level=debug", "-server-address=:9000"}, LogLevelDebug, ":9000"},
+		{"env only", map[string]string{EnvLogLevel: "warn", EnvServerAddress: ":9001"}, nil, LogLevelWarn, ":9001"},
+		{"both, flag wins", map[string]string{EnvLogLevel: "warn", EnvServerAddress: ":9001"}, []string{"-log-level", "error"}, LogLevelError, ":9001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setenv(t, tt.env)
+			fs := newFlagSet()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			c, err := NewWithFlags(fs)
+			if err != nil {
+				t.Fatalf("NewWithFlags() error = %v", err)
+			}
+			if c.LogLevel() != tt.want {
+				t.Errorf("LogLevel() = %v, want %v", c.LogLevel(), tt.want)
+			}
+			if c.ServerAddress() != tt.addr {
+				t.Errorf("ServerAddress() = %v, want %v", c.ServerAddress(), tt.addr)
+			}
+			if tt.args != nil && c.Source(EnvLogLevel) != SourceFlag {
+				t.Errorf("Source(%s) = %v, want %v", EnvLogLevel, c.Source(EnvLogLevel), SourceFlag)
+			}
+		})
+	}
+}
+
+func TestBindFlagsDefaults(t *testing.T) {
+	fs := newFlagSet()
+	for flagName, want := range map[string]string{
+		"log-level":               string(DefaultLogLevel),
+		"server-address":          DefaultServerAddress,
+		"server-read-timeout":     DefaultServerReadTimeout.String(),
+		"server-max-header-bytes": "1048576",
+	} {
+		f := fs.Lookup(flagName)
+		if f == nil {
+			t.Errorf("flag -%s is not bound", flagName)
+			continue
+		}
+		if f.DefValue != want {
+			t.Errorf("flag -%s default = %q, want %q", flagName, f.DefValue, want)
+		}
+	}
+}
+
+func TestBindFlagsValidation(t *testing.T) {
+	for _, args := range [][]string{
+		{"-log-level=loud"},
+		{"-log-format=xml"},
+		{"-server-read-timeout=soon"},
+		{"-server-max-header-bytes=lots"},
+	} {
+		if err := newFlagSet().Parse(args); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an error", args)
+		}
+	}
+	fs := newFlagSet()
+	if err := fs.Parse([]string{"-log-level=DEBUG", "-server-read-timeout=30"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	c, err := NewWithFlags(fs, WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewWithFlags() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelDebug || c.ServerReadTimeout() != 30*time.Second {
+		t.Errorf("got %v and %v, want %v and 30s", c.LogLevel(), c.ServerReadTimeout(), LogLevelDebug)
+	}
+}
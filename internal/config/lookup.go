@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Causes of the [*ParseError] failures reported by the lookup helpers.
+var (
+	errInvalidBool     = errors.New("expected 1, 0, true, false, yes or no")
+	errInvalidDuration = errors.New(`expected a duration (e.g., "5s", "1m") or a number of seconds (e.g., "30", "0.5")`)
+	errNegativeSeconds = errors.New("expected a non-negative number of seconds")
+	errInvalidFileMode = errors.New("expected octal permission bits (e.g., 0660)")
+)
+
+// The lookup helpers read the variable v, falling back to its default when
+// unset. Values failing to parse or validate are reported as errors and replaced
+// by the default, so that loading goes on and reports every failure at once.
+
+// lookupString returns the value of v, checked with validate when not nil. A
+// value matching one of v.values is returned in its canonical form (see
+// canonical).
+func (l *loader) lookupString(v variable, validate func(string) error) string {
+	val, ok := l.find(v)
+	if !ok {
+		return v.fallback
+	}
+	if c, ok := canonical(val.raw, v.values); ok {
+		return c
+	}
+	if validate != nil {
+		if err := validate(val.raw); err != nil {
+			l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: err, kind: v.invalid})
+			return v.fallback
+		}
+	}
+	return val.raw
+}
+
+// lookupEnum returns the value of v among v.values in its canonical form (see
+// canonical).
+func (l *loader) lookupEnum(v variable) string {
+	val, ok := l.find(v)
+	if !ok {
+		return v.fallback
+	}
+	c, ok := canonical(val.raw, v.values)
+	if !ok {
+		l.addError(&ValidationError{Var: val.name, Value: val.shown(), Reason: fmt.Sprintf("allowed=%v", v.values), kind: v.invalid})
+		return v.fallback
+	}
+	return c
+}
+
+// lookupInt returns the decimal integer value of v, which must lie within
+// [minVal, maxVal].
+func (l *loader) lookupInt(v variable, minVal, maxVal int) int {
+	fallback, _ := strconv.Atoi(v.fallback)
+	val, ok := l.find(v)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(val.raw)
+	if err != nil {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: errors.Unwrap(err), kind: ErrInvalidNumber})
+		return fallback
+	}
+	if n < minVal || n > maxVal {
+		l.addError(&ValidationError{Var: val.name, Value: val.shown(), Reason: fmt.Sprintf("expected=[%d, %d]", minVal, maxVal), kind: ErrOutOfRange})
+		return fallback
+	}
+	return n
+}
+
+// lookupFloat returns the floating-point value of v, which must lie within
+// [minVal, maxVal].
+func (l *loader) lookupFloat(v variable, minVal, maxVal float64) float64 {
+	fallback, _ := strconv.ParseFloat(v.fallback, 64)
+	val, ok := l.find(v)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(val.raw, 64)
+	if err != nil {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: errors.Unwrap(err), kind: ErrInvalidNumber})
+		return fallback
+	}
+	if f < minVal || f > maxVal {
+		l.addError(&ValidationError{Var: val.name, Value: val.shown(), Reason: fmt.Sprintf("expected=[%g, %g]", minVal, maxVal), kind: ErrOutOfRange})
+		return fallback
+	}
+	return f
+}
+
+// lookupBool returns the boolean value of v, accepting 1, 0, true, false, yes
+// and no case-insensitively.
+func (l *loader) lookupBool(v variable) bool {
+	fallback, _ := parseBool(v.fallback)
+	val, ok := l.find(v)
+	if !ok {
+		return fallback
+	}
+	b, err := parseBool(val.raw)
+	if err != nil {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: err, kind: ErrInvalidBool})
+		return fallback
+	}
+	return b
+}
+
+// lookupDuration returns the [time.Duration] value of v (see parseDuration).
+// Negative values are errors, while zero (unless v allows it) and values above
+// the ceiling of v are warnings.
+func (l *loader) lookupDuration(v variable) time.Duration {
+	fallback := v.fallbackDuration()
+	val, ok := l.find(v)
+	if !ok {
+		return fallback
+	}
+	d, err := parseDuration(val.raw)
+	if err != nil {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: err, kind: ErrInvalidDuration})
+		return fallback
+	}
+	shown := value{raw: d.String(), sensitive: val.sensitive}.shown()
+	switch ceiling := v.durationCeiling(); {
+	case d < 0:
+		l.addError(&ValidationError{Var: val.name, Value: shown, Reason: "must not be negative", kind: ErrOutOfRange})
+		return fallback
+	case d == 0 && !v.allowZero:
+		l.addWarning(&ValidationError{Var: val.name, Value: shown, Reason: "disables the timeout", kind: ErrOutOfRange})
+	case d > ceiling:
+		l.addWarning(&ValidationError{Var: val.name, Value: shown, Reason: "exceeds " + ceiling.String(), kind: ErrOutOfRange})
+	}
+	return d
+}
+
+// lookupFileMode returns the octal permission bits value of v (e.g., "0660").
+func (l *loader) lookupFileMode(v variable) fs.FileMode {
+	fallback, _ := strconv.ParseUint(v.fallback, 8, 32)
+	val, ok := l.find(v)
+	if !ok {
+		return fs.FileMode(fallback)
+	}
+	m, err := strconv.ParseUint(val.raw, 8, 32)
+	if err != nil || m > uint64(fs.ModePerm) {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: errInvalidFileMode, kind: ErrInvalidFileMode})
+		return fs.FileMode(fallback)
+	}
+	return fs.FileMode(m)
+}
+
+// lookupByteSize returns the number of bytes value of v (see [ParseByteSize]),
+// which must lie within [minVal, maxVal].
+func (l *loader) lookupByteSize(v variable, minVal, maxVal int64) int64 {
+	fallback, _ := ParseByteSize(v.fallback)
+	val, ok := l.find(v)
+	if !ok {
+		return fallback
+	}
+	n, err := ParseByteSize(val.raw)
+	if err != nil {
+		l.addError(&ParseError{Var: val.name, Value: val.shown(), Err: err, kind: ErrInvalidByteSize})
+		return fallback
+	}
+	if n < minVal || n > maxVal {
+		l.addError(&ValidationError{Var: val.name, Value: val.shown(), Reason: fmt.Sprintf("expected=[%d, %d]", minVal, maxVal), kind: ErrOutOfRange})
+		return fallback
+	}
+	return n
+}
+
+// lookupStringSlice returns the value of v split at sep, with every element
+// trimmed and empty elements dropped.
+func (l *loader) lookupStringSlice(v variable, sep string) []string {
+	if val, ok := l.find(v); ok {
+		return splitList(val.raw, sep)
+	}
+	return splitList(v.fallback, sep)
+}
+
+// find returns the value of v, through lookupField for the variables backing
+// [Config] fields and lookup for the others.
+func (l *loader) find(v variable) (value, bool) {
+	if v.load != nil {
+		return l.lookupField(v)
+	}
+	val, ok := l.lookup(v.envKey)
+	val.sensitive = v.sensitive
+	return val, ok
+}
+
+// canonical returns the element of values matching s case-insensitively, once
+// trimmed of surrounding whitespace and quotes.
+func canonical(s string, values []string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	idx := slices.IndexFunc(values, func(v string) bool {
+		return strings.EqualFold(s, v)
+	})
+	if idx < 0 {
+		return "", false
+	}
+	return values[idx], true
+}
+
+// parseDuration parses s as a [time.Duration] (e.g., "5s") or, when it is a bare
+// non-negative number, as a number of seconds (e.g., "30", "0.5").
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errInvalidDuration
+	}
+	if f < 0 {
+		return 0, errNegativeSeconds
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which overflows a Duration.
+	if f*float64(time.Second) >= math.MaxInt64 {
+		return 0, errInvalidDuration
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes":
+		return true, nil
+	case "0", "false", "no":
+		return false, nil
+	}
+	return false, errInvalidBool
+}
+
+func splitList(s, sep string) []string {
+	var list []string
+	for _, e := range strings.Split(s, sep) {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
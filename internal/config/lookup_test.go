@@ -0,0 +1,354 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testVar is the variable read by the lookup helper tests.
+const testVar = "TEST_VAR"
+
+// lookupLoader returns a loader reading testVar as val, or leaving it unset
+// when val is nil.
+func lookupLoader(val *string) *loader {
+	env := map[string]string{}
+	if val != nil {
+		env[testVar] = *val
+	}
+	return newLoader(context.Background(), []Option{WithLookuper(mapLookup(env))})
+}
+
+// ptr returns a pointer to s.
+func ptr(s string) *string {
+	return &s
+}
+
+func TestLookupString(t *testing.T) {
+	errBad := errors.New("bad")
+	validate := func(s string) error {
+		if s == "bad" {
+			return errBad
+		}
+		return nil
+	}
+	v := variable{envKey: testVar, fallback: "def", values: []string{"Canonical"}}
+	tests := []struct {
+		name    string
+		val     *string
+		want    string
+		wantErr bool
+	}{
+		{"unset", nil, "def", false},
+		{"blank", ptr("  "), "def", false},
+		{"set", ptr("value"), "value", false},
+		{"trimmed", ptr("  value \t"), "value", false},
+		{"canonical", ptr("CANONICAL"), "Canonical", false},
+		{"invalid", ptr("bad"), "def", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lookupLoader(tt.val)
+			if got := l.lookupString(v, validate); got != tt.want {
+				t.Errorf("lookupString() = %q, want %q", got, tt.want)
+			}
+			var pe *ParseError
+			if got := errors.As(l.Err(), &pe); got != tt.wantErr {
+				t.Errorf("lookupString() error = %v, want error %v", l.Err(), tt.wantErr)
+			}
+		})
+	}
+	if got := lookupLoader(ptr("bad")).lookupString(v, nil); got != "bad" {
+		t.Errorf("lookupString() without validate = %q, want bad", got)
+	}
+}
+
+func TestLookupInt(t *testing.T) {
+	v := variable{envKey: testVar, fallback: "5"}
+	tests := []struct {
+		name string
+		val  *string
+		want int
+		kind error
+	}{
+		{"unset", nil, 5, nil},
+		{"set", ptr("7"), 7, nil},
+		{"trimmed", ptr(" 8 "), 8, nil},
+		{"minimum", ptr("1"), 1, nil},
+		{"maximum", ptr("10"), 10, nil},
+		{"negative", ptr("-3"), 5, ErrOutOfRange},
+		{"too large", ptr("11"), 5, ErrOutOfRange},
+		{"fraction", ptr("1.5"), 5, ErrInvalidNumber},
+		{"not a number", ptr("many"), 5, ErrInvalidNumber},
+		{"overflow", ptr("99999999999999999999"), 5, ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lookupLoader(tt.val)
+			if got := l.lookupInt(v, 1, 10); got != tt.want {
+				t.Errorf("lookupInt() = %d, want %d", got, tt.want)
+			}
+			checkKind(t, l.Err(), tt.kind)
+		})
+	}
+}
+
+func TestLookupFloat(t *testing.T) {
+	v := variable{envKey: testVar, fallback: "0.5"}
+	tests := []struct {
+		name string
+		val  *string
+		want float64
+		kind error
+	}{
+		{"unset", nil, 0.5, nil},
+		{"set", ptr("0.25"), 0.25, nil},
+		{"integer", ptr("1"), 1, nil},
+		{"exponent", ptr("1e-1"), 0.1, nil},
+		{"below", ptr("-0.1"), 0.5, ErrOutOfRange},
+		{"above", ptr("1.01"), 0.5, ErrOutOfRange},
+		{"infinite", ptr("+Inf"), 0.5, ErrOutOfRange},
+		{"not a number", ptr("half"), 0.5, ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lookupLoader(tt.val)
+			if got := l.lookupFloat(v, 0, 1); got != tt.want {
+				t.Errorf("lookupFloat() = %g, want %g", got, tt.want)
+			}
+			checkKind(t, l.Err(), tt.kind)
+		})
+	}
+	if got := lookupLoader(ptr("NaN")).lookupFloat(v, math.Inf(-1), math.Inf(1)); !math.IsNaN(got) {
+		t.Errorf("lookupFloat(NaN) = %g, want NaN", got)
+	}
+}
+
+func TestLookupBool(t *testing.T) {
+	tests := []struct {
+		val  *string
+		def  string
+		want bool
+		kind error
+	}{
+		{nil, "true", true, nil},
+		{nil, "false", false, nil},
+		{ptr("1"), "false", true, nil},
+		{ptr("true"), "false", true, nil},
+		{ptr("TRUE"), "false", true, nil},
+		{ptr("Yes"), "false", true, nil},
+		{ptr(" yes "), "false", true, nil},
+		{ptr("0"), "true", false, nil},
+		{ptr("false"), "true", false, nil},
+		{ptr("False"), "true", false, nil},
+		{ptr("NO"), "true", false, nil},
+		{ptr("on"), "true", true, ErrInvalidBool},
+		{ptr("2"), "false", false, ErrInvalidBool},
+		{ptr("maybe"), "false", false, ErrInvalidBool},
+	}
+	for _, tt := range tests {
+		name := "unset"
+		if tt.val != nil {
+			name = *tt.val
+		}
+		t.Run(name, func(t *testing.T) {
+			l := lookupLoader(tt.val)
+			if got := l.lookupBool(variable{envKey: testVar, fallback: tt.def}); got != tt.want {
+				t.Errorf("lookupBool() = %v, want %v", got, tt.want)
+			}
+			checkKind(t, l.Err(), tt.kind)
+		})
+	}
+}
+
+func TestLookupDuration(t *testing.T) {
+	v := variable{envKey: testVar, fallback: "5s", ceiling: time.Minute}
+	tests := []struct {
+		name    string
+		val     *string
+		want    time.Duration
+		kind    error
+		warning bool
+	}{
+		{"unset", nil, 5 * time.Second, nil, false},
+		{"duration", ptr("1m"), time.Minute, nil, false},
+		{"seconds", ptr("30"), 30 * time.Second, nil, false},
+		{"negative", ptr("-1s"), 5 * time.Second, ErrOutOfRange, false},
+		{"zero", ptr("0"), 0, nil, true},
+		{"above the ceiling", ptr("2m"), 2 * time.Minute, nil, true},
+		{"invalid", ptr("soon"), 5 * time.Second, ErrInvalidDuration, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lookupLoader(tt.val)
+			if got := l.lookupDuration(v); got != tt.want {
+				t.Errorf("lookupDuration() = %v, want %v", got, tt.want)
+			}
+			checkKind(t, l.Err(), tt.kind)
+			if got := len(l.warnings) > 0; got != tt.warning {
+				t.Errorf("lookupDuration() warnings = %v, want warning %v", l.warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestLookupStringSlice(t *testing.T) {
+	v := variable{envKey: testVar, fallback: "a, b"}
+	tests := []struct {
+		name string
+		val  *string
+		want []string
+	}{
+		{"unset", nil, []string{"a", "b"}},
+		{"single", ptr("x"), []string{"x"}},
+		{"several", ptr("x,y,z"), []string{"x", "y", "z"}},
+		{"trimmed", ptr(" x , y "), []string{"x", "y"}},
+		{"empty elements", ptr("x,,y,"), []string{"x", "y"}},
+		{"separators only", ptr(",,"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lookupLoader(tt.val).lookupStringSlice(v, ","); !slices.Equal(got, tt.want) {
+				t.Errorf("lookupStringSlice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// checkKind checks that err wraps kind, or is nil when kind is nil.
+func checkKind(t *testing.T, err, kind error) {
+	t.Helper()
+	if kind == nil {
+		if err != nil {
+			t.Errorf("error = %v, want none", err)
+		}
+		return
+	}
+	if !errors.Is(err, kind) {
+		t.Errorf("error = %v, want %v", err, kind)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr error
+	}{
+		{"30", 30 * time.Second, nil},
+		{"0.5", 500 * time.Millisecond, nil},
+		{"5s", 5 * time.Second, nil},
+		{"1m30s", 90 * time.Second, nil},
+		{" 2 ", 2 * time.Second, nil},
+		{"0", 0, nil},
+		{"-1s", -time.Second, nil},
+		{"-1", 0, errNegativeSeconds},
+		{"30 sec", 0, errInvalidDuration},
+		{"soon", 0, errInvalidDuration},
+		{"", 0, errInvalidDuration},
+		{"NaN", 0, errInvalidDuration},
+		{"Inf", 0, errInvalidDuration},
+		{"1e20", 0, errInvalidDuration},
+		{"9223372036", 9223372036 * time.Second, nil},
+		{"9223372036.854775807", 0, errInvalidDuration},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.in)
+		if got != tt.want || !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+			t.Errorf("parseDuration(%q) = %v, %v, want %v, %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDurationVariablesAcceptSeconds(t *testing.T) {
+	env := map[string]string{
+		EnvServerReadTimeout:       "30",
+		EnvServerReadHeaderTimeout: "0.5",
+		EnvServerWriteTimeout:      "20",
+		EnvServerIdleTimeout:       "60.25",
+		EnvServerShutdownTimeout:   "25",
+	}
+	c, err := New(WithLookuper(mapLookup(env)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got := []time.Duration{c.ServerReadTimeout(), c.ServerReadHeaderTimeout(), c.ServerWriteTimeout(), c.ServerIdleTimeout(), c.ServerShutdownTimeout()}
+	want := []time.Duration{30 * time.Second, 500 * time.Millisecond, 20 * time.Second, 60250 * time.Millisecond, 25 * time.Second}
+	if !slices.Equal(got, want) {
+		t.Errorf("timeouts = %v, want %v", got, want)
+	}
+
+	for envKey := range env {
+		_, err := New(WithLookuper(mapLookup(map[string]string{envKey: "30 sec"})))
+		if !errors.Is(err, ErrInvalidDuration) || !strings.Contains(err.Error(), errInvalidDuration.Error()) {
+			t.Errorf("New() with %s=\"30 sec\" error = %v, want one mentioning both accepted forms", envKey, err)
+		}
+		_, err = New(WithLookuper(mapLookup(map[string]string{envKey: "-1"})))
+		if !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("New() with %s=-1 error = %v, want %v", envKey, err, ErrInvalidDuration)
+		}
+	}
+}
+
+func TestDurationBounds(t *testing.T) {
+	var durations []variable
+	for _, v := range registry {
+		if v.kind == VarTypeDuration {
+			durations = append(durations, v)
+		}
+	}
+	tests := []struct {
+		name string
+		val  func(v variable) time.Duration
+		want func(v variable) string
+	}{
+		{"negative", func(variable) time.Duration { return -time.Nanosecond }, func(variable) string { return "error" }},
+		{"zero", func(variable) time.Duration { return 0 }, func(v variable) string {
+			if v.allowZero {
+				return ""
+			}
+			return "disables the timeout"
+		}},
+		{"just under the ceiling", func(v variable) time.Duration { return v.durationCeiling() - time.Nanosecond }, func(variable) string { return "" }},
+		{"at the ceiling", func(v variable) time.Duration { return v.durationCeiling() }, func(variable) string { return "" }},
+		{"just over the ceiling", func(v variable) time.Duration { return v.durationCeiling() + time.Nanosecond }, func(v variable) string {
+			return "exceeds " + v.durationCeiling().String()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setting every duration alike keeps the consistency rules satisfied.
+			env := map[string]string{}
+			for _, v := range durations {
+				env[v.envKey] = tt.val(v).String()
+			}
+			c, err := New(WithLookuper(mapLookup(env)))
+			for _, v := range durations {
+				want := tt.want(v)
+				shown := `(` + v.envKey + `) got="` + tt.val(v).String() + `"`
+				switch {
+				case want == "error":
+					if !errors.Is(err, ErrOutOfRange) || !strings.Contains(err.Error(), shown+" must not be negative") {
+						t.Errorf("New() error = %v, want %s rejected", err, shown)
+					}
+					continue
+				case err != nil:
+					t.Fatalf("New() error = %v", err)
+				}
+				i := slices.IndexFunc(c.Warnings(), func(w string) bool { return strings.Contains(w, "("+v.envKey+")") })
+				switch {
+				case want == "" && i >= 0:
+					t.Errorf("unexpected warning %q", c.Warnings()[i])
+				case want != "" && i < 0:
+					t.Errorf("no warning for %s, want %q", shown, want)
+				case want != "" && c.Warnings()[i] != "invalid configuration "+shown+" "+want:
+					t.Errorf("warning = %q, want %q", c.Warnings()[i], "invalid configuration "+shown+" "+want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Manager holds the current application configuration and replaces it when
+	// reloaded, notifying the subscribers of every reload.
+	//
+	// A Manager is safe for concurrent use.
+	Manager struct {
+		opts     []Option
+		current  atomic.Pointer[Config]
+		reload   sync.Mutex
+		mu       sync.Mutex
+		subs     []func(*Config, error)
+		onChange []func(Diff)
+		sigs     chan os.Signal
+		watching bool
+		done     chan struct{}
+		wg       sync.WaitGroup
+		closed   bool
+	}
+)
+
+// NewManager creates and returns a new [Manager] holding the application
+// configuration loaded by [New] with opts. The same options are used by every
+// reload.
+//
+// If the initial application configuration cannot be loaded or validated, the
+// error is returned.
+func NewManager(opts ...Option) (*Manager, error) {
+	cfg, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{
+		opts: opts,
+		done: make(chan struct{}),
+	}
+	m.current.Store(cfg)
+	return m, nil
+}
+
+// Current returns the current application configuration.
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// Reload runs the full load and validation pipeline again and, on success,
+// replaces the current application configuration. On failure the previous
+// configuration stays in effect. Either way the subscribers are notified, in
+// registration order, before Reload returns the error, if any. A panic in a
+// subscriber is recovered and does not affect the others or the [Manager].
+//
+// Reloads, notifications included, are serialized. Subscribers may call
+// [Manager.Current], [Manager.Subscribe] and [Manager.OnChange], whose
+// registrations take effect from the next reload, but must not call Reload or
+// [Manager.Close], which wait for the reload in progress.
+func (m *Manager) Reload() error {
+	m.reload.Lock()
+	defer m.reload.Unlock()
+	prev := m.current.Load()
+	cfg, err := New(m.opts...)
+	if err == nil {
+		m.current.Store(cfg)
+	}
+	cur := m.current.Load()
+	m.mu.Lock()
+	subs, onChange := slices.Clone(m.subs), slices.Clone(m.onChange)
+	m.mu.Unlock()
+	for _, fn := range subs {
+		protect(func() { fn(cur, err) })
+	}
+	if diff := prev.Diff(cur); len(diff) > 0 {
+		for _, fn := range onChange {
+			protect(func() { fn(diff) })
+		}
+	}
+	return err
+}
+
+// Subscribe registers fn to be called after every reload with the current
+// application configuration and the reload error, if any. A failed reload calls
+// fn with the previous, still current, configuration and a non-nil error.
+func (m *Manager) Subscribe(fn func(cfg *Config, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs = append(m.subs, fn)
+}
+
+// OnChange registers fn to be called after every successful reload that changed
+// at least one field, with the changes from the previous configuration.
+func (m *Manager) OnChange(fn func(diff Diff)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// ReloadOnSignal reloads the application configuration whenever the process
+// receives one of sigs, or SIGHUP when none is given, until [Manager.Close] is
+// called. Reload errors are only reported to the subscribers.
+func (m *Manager) ReloadOnSignal(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = reloadSignals
+	}
+	if len(sigs) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed || m.sigs != nil {
+		return
+	}
+	m.sigs = make(chan os.Signal, 1)
+	signal.Notify(m.sigs, sigs...)
+	m.wg.Go(func() {
+		for {
+			select {
+			case <-m.sigs:
+				_ = m.Reload()
+			case <-m.done:
+				return
+			}
+		}
+	})
+}
+
+// WatchFile polls the configuration file named by [EnvConfigFile] every
+// [EnvConfigWatchInterval] and reloads the application configuration when its
+// contents change, until [Manager.Close] is called. It does nothing when no
+// configuration file is in use or the interval is zero.
+//
+// Changes are detected by content checksum, re-resolving the path on every poll,
+// so files replaced by an atomic rename (as editors and Kubernetes do) are picked
+// up while metadata-only changes are ignored. A change is applied once the
+// contents have been stable for a whole interval, coalescing rapid successive
+// writes into a single reload. Reload errors are only reported to the
+// subscribers.
+func (m *Manager) WatchFile() {
+	cfg := m.Current()
+	if cfg.configFile == "" || cfg.watchInterval <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed || m.watching {
+		return
+	}
+	m.watching = true
+	applied := fileChecksum(cfg.configFile)
+	m.wg.Go(func() {
+		ticker := time.NewTicker(cfg.watchInterval)
+		defer ticker.Stop()
+		seen := applied
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.done:
+				return
+			}
+			sum := fileChecksum(m.Current().configFile)
+			switch {
+			case !bytes.Equal(sum, seen):
+				seen = sum
+			case !bytes.Equal(sum, applied):
+				applied = sum
+				_ = m.Reload()
+			}
+		}
+	})
+}
+
+// protect calls fn, recovering from any panic.
+func protect(fn func()) {
+	defer func() {
+		_ = recover()
+	}()
+	fn()
+}
+
+// fileChecksum returns the checksum of the contents of the file at path, or nil
+// when it cannot be read.
+func fileChecksum(path string) []byte {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+// Close stops the background reloads started by the [Manager] and waits for
+// them to return. The current application configuration remains available.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	if m.sigs != nil {
+		signal.Stop(m.sigs)
+	}
+	close(m.done)
+	m.mu.Unlock()
+	m.wg.Wait()
+	return nil
+}
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// mutableEnv is an environment that tests can change between reloads.
+type mutableEnv struct {
+	mu  sync.Mutex
+	env map[string]string
+}
+
+func (e *mutableEnv) set(key, value string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.env == nil {
+		e.env = map[string]string{}
+	}
+	e.env[key] = value
+}
+
+func (e *mutableEnv) lookup(key string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	val, ok := e.env[key]
+	return val, ok
+}
+
+func TestManagerReload(t *testing.T) {
+	env := &mutableEnv{}
+	env.set(EnvLogLevel, "info")
+	m, err := NewManager(WithLookuper(env.lookup))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer m.Close()
+
+	var got []string
+	m.Subscribe(func(cfg *Config, err error) {
+		got = append(got, "first "+string(cfg.LogLevel())+" "+errString(err))
+	})
+	m.Subscribe(func(cfg *Config, err error) {
+		got = append(got, "second "+string(cfg.LogLevel())+" "+errString(err))
+	})
+
+	env.set(EnvLogLevel, "debug")
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if m.Current().LogLevel() != LogLevelDebug {
+		t.Errorf("Current().LogLevel() = %v, want %v", m.Current().LogLevel(), LogLevelDebug)
+	}
+
+	env.set(EnvLogLevel, "loud")
+	if err := m.Reload(); err == nil {
+		t.Fatal("Reload() error = nil, want an error")
+	}
+	if m.Current().LogLevel() != LogLevelDebug {
+		t.Errorf("Current().LogLevel() = %v, want the previous %v", m.Current().LogLevel(), LogLevelDebug)
+	}
+
+	want := []string{"first debug ", "second debug ", "first debug failed", "second debug failed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("notifications = %q, want %q", got, want)
+	}
+}
+
+// errString returns "failed" for a non-nil err, so that notifications can be
+// compared without the full error text.
+func errString(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return ""
+}
+
+func TestNewManagerError(t *testing.T) {
+	m, err := NewManager(WithLookuper(mapLookup(map[string]string{EnvLogLevel: "loud"})))
+	if err == nil || m != nil {
+		t.Errorf("NewManager() = %v, %v, want nil and an error", m, err)
+	}
+}
+
+func TestManagerSubscriberPanic(t *testing.T) {
+	m, err := NewManager(WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer m.Close()
+	m.Subscribe(func(*Config, error) { panic("boom") })
+	called := false
+	m.Subscribe(func(*Config, error) { called = true })
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !called {
+		t.Error("a panicking subscriber prevented the next one from being called")
+	}
+}
+
+func TestManagerOnChange(t *testing.T) {
+	env := &mutableEnv{}
+	m, err := NewManager(WithLookuper(env.lookup))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer m.Close()
+	var diffs []Diff
+	m.OnChange(func(Diff) { panic("boom") })
+	m.OnChange(func(diff Diff) { diffs = append(diffs, diff) })
+
+	// A reload changing nothing, and a failed one, do not fire.
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	env.set(EnvLogLevel, "loud")
+	if err := m.Reload(); err == nil {
+		t.Fatal("Reload() error = nil, want an error")
+	}
+	if len(diffs) > 0 {
+		t.Fatalf("OnChange fired for %v, want no calls", diffs)
+	}
+
+	env.set(EnvLogLevel, "debug")
+	env.set(EnvServerAddress, ":9090")
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	want := Diff{
+		{Field: EnvLogLevel, Old: string(DefaultLogLevel), New: "debug"},
+		{Field: EnvServerAddress, Old: DefaultServerAddress, New: ":9090"},
+	}
+	if len(diffs) != 1 || !slices.Equal(diffs[0], want) {
+		t.Errorf("OnChange calls = %v, want one with %v", diffs, want)
+	}
+	if m.Current().ServerAddress() != ":9090" {
+		t.Errorf("ServerAddress() = %v, want :9090 despite the panicking callback", m.Current().ServerAddress())
+	}
+}
+
+func TestManagerReloadOnSignal(t *testing.T) {
+	env := &mutableEnv{}
+	m, err := NewManager(WithLookuper(env.lookup))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	reloaded := make(chan *Config, 1)
+	m.Subscribe(func(cfg *Config, _ error) { reloaded <- cfg })
+	m.ReloadOnSignal()
+
+	env.set(EnvLogLevel, "warn")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cfg := <-reloaded:
+		if cfg.LogLevel() != LogLevelWarn {
+			t.Errorf("LogLevel() = %v, want %v", cfg.LogLevel(), LogLevelWarn)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after SIGHUP")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if m.Current().LogLevel() != LogLevelWarn {
+		t.Errorf("Current() after Close = %v, want %v", m.Current().LogLevel(), LogLevelWarn)
+	}
+}
+
+// watchInterval is the polling interval used by the file watcher tests.
+const watchInterval = 20 * time.Millisecond
+
+// watchedManager returns a [Manager] watching a configuration file in a
+// temporary directory, initially holding content, and a channel receiving the
+// reload errors.
+func watchedManager(t *testing.T, content string) (*Manager, string, <-chan error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, content)
+	m, err := NewManager(WithLookuper(mapLookup(map[string]string{
+		EnvConfigFile:          path,
+		EnvConfigWatchInterval: watchInterval.String(),
+	})))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	reloads := make(chan error, 16)
+	m.Subscribe(func(_ *Config, err error) { reloads <- err })
+	m.WatchFile()
+	return m, path, reloads
+}
+
+// expectReloads waits until n reloads happened, then checks that no other
+// follows within a few polling intervals, and returns the last reload error.
+func expectReloads(t *testing.T, reloads <-chan error, n int) error {
+	t.Helper()
+	var err error
+	for i := range n {
+		select {
+		case err = <-reloads:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d reloads, want %d", i, n)
+		}
+	}
+	select {
+	case <-reloads:
+		t.Fatalf("got more than %d reloads", n)
+	case <-time.After(10 * watchInterval):
+	}
+	return err
+}
+
+func TestManagerWatchFile(t *testing.T) {
+	m, path, reloads := watchedManager(t, "log:\n  level: info\n")
+	expectReloads(t, reloads, 0)
+
+	writeFile(t, path, "log:\n  level: debug\n")
+	if err := expectReloads(t, reloads, 1); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if m.Current().LogLevel() != LogLevelDebug {
+		t.Errorf("LogLevel() = %v, want %v", m.Current().LogLevel(), LogLevelDebug)
+	}
+
+	// Rewriting the same contents is not a change.
+	writeFile(t, path, "log:\n  level: debug\n")
+	expectReloads(t, reloads, 0)
+}
+
+func TestManagerWatchFileAtomicRename(t *testing.T) {
+	m, path, reloads := watchedManager(t, "log:\n  level: info\n")
+	tmp := filepath.Join(filepath.Dir(path), ".config.yaml.tmp")
+	writeFile(t, tmp, "log:\n  level: warn\n")
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := expectReloads(t, reloads, 1); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if m.Current().LogLevel() != LogLevelWarn {
+		t.Errorf("LogLevel() = %v, want %v", m.Current().LogLevel(), LogLevelWarn)
+	}
+}
+
+func TestManagerWatchFileDebounce(t *testing.T) {
+	m, path, reloads := watchedManager(t, "log:\n  level: info\n")
+	for _, level := range []string{"debug", "warn", "error"} {
+		writeFile(t, path, "log:\n  level: "+level+"\n")
+	}
+	if err := expectReloads(t, reloads, 1); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if m.Current().LogLevel() != LogLevelError {
+		t.Errorf("LogLevel() = %v, want %v", m.Current().LogLevel(), LogLevelError)
+	}
+}
+
+func TestManagerWatchFileInvalid(t *testing.T) {
+	m, path, reloads := watchedManager(t, "log:\n  level: info\n")
+	writeFile(t, path, "log:\n  level: loud\n")
+	if err := expectReloads(t, reloads, 1); err == nil {
+		t.Fatal("reload error = nil, want an error")
+	}
+	if m.Current().LogLevel() != LogLevelInfo {
+		t.Errorf("LogLevel() = %v, want the previous %v", m.Current().LogLevel(), LogLevelInfo)
+	}
+}
+
+func TestManagerWatchFileClose(t *testing.T) {
+	m, path, reloads := watchedManager(t, "log:\n  level: info\n")
+	closed := make(chan struct{})
+	go func() {
+		m.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return; the watcher goroutine leaked")
+	}
+	writeFile(t, path, "log:\n  level: debug\n")
+	expectReloads(t, reloads, 0)
+
+	// Watching a closed Manager does not start a new goroutine.
+	m.WatchFile()
+	expectReloads(t, reloads, 0)
+}
+
+func TestManagerWatchFileDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "log:\n  level: info\n")
+	m, err := NewManager(WithLookuper(mapLookup(map[string]string{
+		EnvConfigFile:          path,
+		EnvConfigWatchInterval: "0",
+	})))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer m.Close()
+	reloads := make(chan error, 1)
+	m.Subscribe(func(_ *Config, err error) { reloads <- err })
+	m.WatchFile()
+	writeFile(t, path, "log:\n  level: debug\n")
+	expectReloads(t, reloads, 0)
+}
+
+func TestManagerCallbacksReenter(t *testing.T) {
+	m, err := NewManager(WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer m.Close()
+	var late int
+	m.Subscribe(func(cfg *Config, _ error) {
+		if m.Current() != cfg {
+			t.Error("Current() does not return the notified configuration")
+		}
+		m.Subscribe(func(*Config, error) { late++ })
+		m.OnChange(func(Diff) {})
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Reload()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Reload() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload() deadlocked on a subscriber registering callbacks")
+	}
+	if late != 0 {
+		t.Errorf("a subscriber registered during a reload was called %d times by it", late)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if late != 1 {
+		t.Errorf("the subscriber registered during the first reload was called %d times, want 1", late)
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MarshalJSON implements [json.Marshaler], encoding the effective configuration
+// with the layout of the configuration file (see [NewFromFile]):
+//
+//	{
+//	  "app": {"env": "development"},
+//	  "log": {"format": "text", "level": "info", "output": "stdout"},
+//	  "server": {"address": "localhost:8080", "idle_timeout": "1m0s", ...}
+//	}
+//
+// The "app" section reports the active [Environment] ([EnvAppEnv]), which cannot
+// be set from the configuration file.
+// Durations are encoded as [time.Duration.String] does, keys are sorted and the
+// values of sensitive variables are replaced by "***", so the output is safe to
+// log.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]map[string]string)
+	for _, v := range registry {
+		if v.value == nil {
+			continue
+		}
+		section, key, _ := strings.Cut(fileKey(v.envKey), ".")
+		if doc[section] == nil {
+			doc[section] = make(map[string]string)
+		}
+		val := v.value(c)
+		if v.sensitive {
+			val = redacted
+		}
+		doc[section][key] = val
+	}
+	return json.Marshal(doc)
+}
+
+// String returns the JSON encoding of c (see [Config.MarshalJSON]), so that
+// formatting a [Config] never leaks sensitive values.
+func (c *Config) String() string {
+	if c == nil {
+		return "<nil>"
+	}
+	b, err := c.MarshalJSON()
+	if err != nil {
+		return "<invalid config: " + err.Error() + ">"
+	}
+	return string(b)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	c, err := NewFromMap(map[string]string{
+		EnvAppEnv:            "staging",
+		EnvLogLevel:          "debug",
+		EnvServerReadTimeout: "2.5",
+		EnvServerIdleTimeout: "90s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		t.Fatalf("json.MarshalIndent() error = %v", err)
+	}
+	golden := filepath.Join("testdata", "config.json")
+	if *update {
+		if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got)+"\n" != string(want) {
+		t.Errorf("MarshalJSON() =\n%s\nwant\n%s", got, want)
+	}
+
+	var doc map[string]map[string]string
+	if err := json.Unmarshal(got, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["server"]["read_timeout"] != "2.5s" || doc["server"]["idle_timeout"] != "1m30s" {
+		t.Errorf("durations encoded as %q and %q, want 2.5s and 1m30s", doc["server"]["read_timeout"], doc["server"]["idle_timeout"])
+	}
+	if doc["app"]["env"] != "staging" {
+		t.Errorf("app.env = %q, want staging", doc["app"]["env"])
+	}
+}
+
+func TestMarshalJSONRedacted(t *testing.T) {
+	markSensitive(t, EnvServerAddress)
+	c, err := NewFromMap(map[string]string{EnvServerAddress: "10.0.0.1:8443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for name, got := range map[string]string{
+		"MarshalJSON": string(b),
+		"String":      c.String(),
+		"%v":          fmt.Sprintf("%v", c),
+	} {
+		if strings.Contains(got, "10.0.0.1") {
+			t.Errorf("%s leaks the sensitive value: %s", name, got)
+		}
+		if !strings.Contains(got, `"address":"***"`) {
+			t.Errorf("%s = %s, want the address redacted", name, got)
+		}
+	}
+}
+
+func TestStringNil(t *testing.T) {
+	var c *Config
+	if got := c.String(); got != "<nil>" {
+		t.Errorf("String() = %q, want <nil>", got)
+	}
+}
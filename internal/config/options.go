@@ -0,0 +1,185 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type (
+	// Option customizes how the application configuration is loaded.
+	Option func(*loader)
+)
+
+// WithLookuper returns an [Option] replacing the process environment with
+// lookup, which reports the value of a variable and whether it is set (see
+// [os.LookupEnv]). It is consulted for every variable, including [EnvPrefix],
+// [EnvConfigEnvFile] and [EnvConfigFile]. No env file is discovered implicitly,
+// so that the configuration does not depend on the working directory; one is
+// only read when named by [EnvConfigEnvFile]. Since lookup cannot enumerate its
+// variables, unrecognized variables are only detected in the env file.
+func WithLookuper(lookup func(key string) (string, bool)) Option {
+	return func(l *loader) {
+		if lookup != nil {
+			l.getenv = lookup
+			l.environ = nil
+			withoutEnvFileDiscovery()(l)
+		}
+	}
+}
+
+// WithPrefix returns an [Option] reading every variable under its prefixed name
+// first, as [NewWithPrefix] does, instead of the prefix set by [EnvPrefix].
+func WithPrefix(prefix string) Option {
+	return func(l *loader) {
+		l.prefix = prefix
+		l.hasPrefix = true
+	}
+}
+
+// WithLogLevel returns an [Option] overriding the configured [LogLevel].
+func WithLogLevel(level LogLevel) Option {
+	return withOverride(EnvLogLevel, "WithLogLevel", string(level))
+}
+
+// WithLogFormat returns an [Option] overriding the configured [LogFormat].
+func WithLogFormat(format LogFormat) Option {
+	return withOverride(EnvLogFormat, "WithLogFormat", string(format))
+}
+
+// WithLogOutput returns an [Option] overriding the configured [LogOutput].
+func WithLogOutput(output LogOutput) Option {
+	return withOverride(EnvLogOutput, "WithLogOutput", string(output))
+}
+
+// WithServerAddress returns an [Option] overriding the configured server's
+// address.
+func WithServerAddress(address string) Option {
+	return withOverride(EnvServerAddress, "WithServerAddress", address)
+}
+
+// WithServerReadTimeout returns an [Option] overriding the configured server's
+// read timeout.
+func WithServerReadTimeout(d time.Duration) Option {
+	return withOverride(EnvServerReadTimeout, "WithServerReadTimeout", d.String())
+}
+
+// WithServerReadHeaderTimeout returns an [Option] overriding the configured
+// server's read header timeout.
+func WithServerReadHeaderTimeout(d time.Duration) Option {
+	return withOverride(EnvServerReadHeaderTimeout, "WithServerReadHeaderTimeout", d.String())
+}
+
+// WithServerWriteTimeout returns an [Option] overriding the configured server's
+// write timeout.
+func WithServerWriteTimeout(d time.Duration) Option {
+	return withOverride(EnvServerWriteTimeout, "WithServerWriteTimeout", d.String())
+}
+
+// WithServerIdleTimeout returns an [Option] overriding the configured server's
+// idle timeout.
+func WithServerIdleTimeout(d time.Duration) Option {
+	return withOverride(EnvServerIdleTimeout, "WithServerIdleTimeout", d.String())
+}
+
+// WithServerShutdownTimeout returns an [Option] overriding the configured
+// server's shutdown timeout.
+func WithServerShutdownTimeout(d time.Duration) Option {
+	return withOverride(EnvServerShutdownTimeout, "WithServerShutdownTimeout", d.String())
+}
+
+// withOverride returns an [Option] setting envKey to value with the highest
+// precedence. The value still goes through the loader's parsing and validation,
+// with failures reported under name. A blank value is an error, since it would
+// bypass the validation of the variable.
+func withOverride(envKey, name, value string) Option {
+	return func(l *loader) {
+		if strings.TrimSpace(value) == "" {
+			l.addError(&ValidationError{Var: name, Reason: "must not be empty", kind: ErrMissingRequired})
+			return
+		}
+		if l.overrides == nil {
+			l.overrides = make(map[string]override)
+		}
+		l.overrides[envKey] = override{name: name, value: value}
+	}
+}
+
+// withoutEnvFileDiscovery returns an [Option] skipping the env file unless it is
+// explicitly named by [EnvConfigEnvFile].
+func withoutEnvFileDiscovery() Option {
+	return func(l *loader) {
+		l.noEnvFile = true
+	}
+}
+
+// withMap returns an [Option] replacing the process environment with values,
+// whose keys are enumerated for the detection of unrecognized variables.
+func withMap(values map[string]string) Option {
+	return func(l *loader) {
+		l.getenv = func(key string) (string, bool) {
+			val, ok := values[key]
+			return val, ok
+		}
+		withoutEnvFileDiscovery()(l)
+		l.environ = func() []string {
+			var environ []string
+			for k, v := range values {
+				environ = append(environ, k+"="+v)
+			}
+			return environ
+		}
+	}
+}
+
+// withWarningsAsErrors returns an [Option] reporting the warnings as errors, as
+// the strict mode does, without requiring every variable to be set.
+func withWarningsAsErrors() Option {
+	return func(l *loader) {
+		l.warnAsError = true
+	}
+}
+
+// Strict returns an [Option] enabling the strict mode regardless of
+// [EnvConfigStrict]: every configuration variable without a value is an error
+// instead of falling back to its default, unless marked with [Optional].
+func Strict() Option {
+	return func(l *loader) {
+		l.strict = true
+		l.hasStrict = true
+	}
+}
+
+// Optional returns an [Option] exempting the variables envKeys (Env* constants)
+// from the strict mode, letting them fall back to their defaults when unset.
+func Optional(envKeys ...string) Option {
+	return func(l *loader) {
+		l.optional = append(l.optional, envKeys...)
+	}
+}
+
+// IgnoreUnknown returns an [Option] excluding the variables names from the
+// detection of unrecognized LOG_* and SERVER_* variables, in addition to the
+// ones set by common platforms (e.g., SERVER_SOFTWARE).
+func IgnoreUnknown(names ...string) Option {
+	return func(l *loader) {
+		l.ignored = append(slices.Clip(l.ignored), names...)
+	}
+}
+
+// WithDirSource returns an [Option] reading configuration values from the files
+// of the directory at path, as named by [EnvConfigDir], which it supersedes.
+// Values from the directory are layered below the environment variables.
+func WithDirSource(path string) Option {
+	return func(l *loader) {
+		l.dir = path
+	}
+}
+
+// WithProviders returns an [Option] reading configuration values from providers,
+// in order of precedence, below the configuration file and above the defaults.
+func WithProviders(providers ...Provider) Option {
+	return func(l *loader) {
+		l.providers = append(l.providers, providers...)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLookuper(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		level   LogLevel
+		format  LogFormat
+		address string
+	}{
+		{"empty", map[string]string{}, DefaultLogLevel, DefaultLogFormat, DefaultServerAddress},
+		{"debug", map[string]string{EnvLogLevel: "debug", EnvServerAddress: ":9001"}, LogLevelDebug, DefaultLogFormat, ":9001"},
+		{"json", map[string]string{EnvLogFormat: "json", EnvServerAddress: ":9002"}, DefaultLogLevel, LogFormatJSON, ":9002"},
+		{"error", map[string]string{EnvLogLevel: "error", EnvServerAddress: "[::1]:9003"}, LogLevelError, DefaultLogFormat, "[::1]:9003"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(WithLookuper(mapLookup(tt.env)))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if c.LogLevel() != tt.level || c.LogFormat() != tt.format || c.ServerAddress() != tt.address {
+				t.Errorf("got %v, %v and %v, want %v, %v and %v",
+					c.LogLevel(), c.LogFormat(), c.ServerAddress(), tt.level, tt.format, tt.address)
+			}
+		})
+	}
+}
+
+func TestWithLookuperSkipsEnvFileDiscovery(t *testing.T) {
+	setenv(t, nil)
+	writeFile(t, ".env", "LOG_LEVEL=debug\n")
+	c, err := New(WithLookuper(mapLookup(nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != DefaultLogLevel {
+		t.Errorf("LogLevel() = %v, want the default %v", c.LogLevel(), DefaultLogLevel)
+	}
+	c, err = New(WithLookuper(mapLookup(map[string]string{EnvConfigEnvFile: ".env"})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelDebug {
+		t.Errorf("LogLevel() = %v, want the explicit env file value %v", c.LogLevel(), LogLevelDebug)
+	}
+}
+
+func TestWithLookuperNil(t *testing.T) {
+	setenv(t, map[string]string{EnvLogLevel: "warn"})
+	c, err := New(WithLookuper(nil))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelWarn {
+		t.Errorf("LogLevel() = %v, want the process environment value %v", c.LogLevel(), LogLevelWarn)
+	}
+}
+
+func TestOverrideOptions(t *testing.T) {
+	env := mapLookup(map[string]string{
+		EnvLogLevel:                "warn",
+		EnvServerAddress:           ":9000",
+		EnvServerShutdownTimeout:   "20s",
+		EnvServerReadHeaderTimeout: "1s",
+	})
+	c, err := New(WithLookuper(env),
+		WithLogLevel(LogLevelDebug),
+		WithLogFormat(LogFormatJSON),
+		WithLogOutput(LogOutputStderr),
+		WithServerAddress(":0"),
+		WithServerReadTimeout(3*time.Second),
+		WithServerReadHeaderTimeout(2*time.Second),
+		WithServerWriteTimeout(4*time.Second),
+		WithServerIdleTimeout(5*time.Second),
+		WithServerShutdownTimeout(6*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got := []any{
+		c.LogLevel(), c.LogFormat(), c.LogOutput(), c.ServerAddress(),
+		c.ServerReadTimeout(), c.ServerReadHeaderTimeout(), c.ServerWriteTimeout(), c.ServerIdleTimeout(), c.ServerShutdownTimeout(),
+	}
+	want := []any{
+		LogLevelDebug, LogFormatJSON, LogOutputStderr, ":0",
+		3 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 6 * time.Second,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %v, want the override %v", i, got[i], want[i])
+		}
+	}
+	if c.Source(EnvLogLevel) != SourceOverride {
+		t.Errorf("Source(%s) = %v, want %v", EnvLogLevel, c.Source(EnvLogLevel), SourceOverride)
+	}
+}
+
+func TestOverrideOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+		want string
+	}{
+		{"invalid format", WithLogFormat("xml"), `(WithLogFormat) got="xml"`},
+		{"invalid level", WithLogLevel("loud"), `(WithLogLevel) got="loud"`},
+		{"invalid address", WithServerAddress("nowhere"), `(WithServerAddress) got="nowhere"`},
+		{"negative timeout", WithServerReadTimeout(-time.Second), `(WithServerReadTimeout) got="-1s"`},
+		{"blank output", WithLogOutput(""), "(WithLogOutput) must not be empty"},
+		{"blank address", WithServerAddress(" "), "(WithServerAddress) must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := New(WithLookuper(mapLookup(nil)), tt.opt)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoOptions(t *testing.T) {
+	setenv(t, map[string]string{EnvLogLevel: "warn", EnvServerWriteTimeout: "30s"})
+	want, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := New([]Option{}...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("New() with no options = %s, want %s", got, want)
+	}
+	if got.ServerWriteTimeout() != 30*time.Second {
+		t.Errorf("ServerWriteTimeout() = %v, want the environment value 30s", got.ServerWriteTimeout())
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+// profileDefaults holds the defaults of each [Environment] departing from the
+// regular ones, keyed by the Env* constants.
+var profileDefaults = map[Environment]map[string]string{
+	EnvironmentStaging: {
+		EnvLogFormat:     string(LogFormatJSON),
+		EnvServerAddress: "0.0.0.0:8080",
+	},
+	EnvironmentProduction: {
+		EnvLogFormat:     string(LogFormatJSON),
+		EnvServerAddress: "0.0.0.0:8080",
+	},
+}
+
+// profileDefault returns the default of envKey for the configured [Environment],
+// if it departs from the regular one.
+func (l *loader) profileDefault(envKey string) (value, bool) {
+	val, ok := profileDefaults[l.environment][envKey]
+	if !ok {
+		return value{}, false
+	}
+	return value{raw: val, name: EnvName(l.prefix, EnvAppEnv) + "=" + string(l.environment), source: SourceDefault}, true
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProfileDefaults(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    Environment
+		level   LogLevel
+		format  LogFormat
+		address string
+	}{
+		{"", EnvironmentDevelopment, DefaultLogLevel, LogFormatText, "localhost:8080"},
+		{"development", EnvironmentDevelopment, DefaultLogLevel, LogFormatText, "localhost:8080"},
+		{"staging", EnvironmentStaging, DefaultLogLevel, LogFormatJSON, "0.0.0.0:8080"},
+		{"production", EnvironmentProduction, LogLevelInfo, LogFormatJSON, "0.0.0.0:8080"},
+		{" Production ", EnvironmentProduction, LogLevelInfo, LogFormatJSON, "0.0.0.0:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Parallel()
+			c, err := New(WithLookuper(mapLookup(map[string]string{EnvAppEnv: tt.env})))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if c.Environment() != tt.want {
+				t.Errorf("Environment() = %v, want %v", c.Environment(), tt.want)
+			}
+			if c.LogLevel() != tt.level || c.LogFormat() != tt.format || c.ServerAddress() != tt.address {
+				t.Errorf("got %v, %v and %v, want %v, %v and %v",
+					c.LogLevel(), c.LogFormat(), c.ServerAddress(), tt.level, tt.format, tt.address)
+			}
+			if c.Source(EnvServerAddress) != SourceDefault {
+				t.Errorf("Source(%s) = %v, want %v", EnvServerAddress, c.Source(EnvServerAddress), SourceDefault)
+			}
+		})
+	}
+}
+
+func TestProfileDefaultsOverridden(t *testing.T) {
+	c, err := New(WithLookuper(mapLookup(map[string]string{
+		EnvAppEnv:        "production",
+		EnvLogFormat:     "text",
+		EnvServerAddress: ":9000",
+	})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogFormat() != LogFormatText || c.ServerAddress() != ":9000" {
+		t.Errorf("got %v and %v, want the explicit %v and :9000", c.LogFormat(), c.ServerAddress(), LogFormatText)
+	}
+
+	c, err = New(WithLookuper(mapLookup(map[string]string{EnvAppEnv: "production"})), WithServerAddress(":9001"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ServerAddress() != ":9001" {
+		t.Errorf("ServerAddress() = %v, want the override :9001", c.ServerAddress())
+	}
+}
+
+func TestProfileDefaultsBuildDefault(t *testing.T) {
+	setBuildDefault(t, &DefaultServerAddressStr, ":9000")
+	c, err := New(WithLookuper(mapLookup(map[string]string{EnvAppEnv: "production"})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ServerAddress() != ":9000" {
+		t.Errorf("ServerAddress() = %v, want the build-time default :9000", c.ServerAddress())
+	}
+	if c.LogFormat() != LogFormatJSON {
+		t.Errorf("LogFormat() = %v, want the profile default %v", c.LogFormat(), LogFormatJSON)
+	}
+}
+
+func TestProfileInvalid(t *testing.T) {
+	_, err := New(WithLookuper(mapLookup(map[string]string{EnvAppEnv: "prod"})))
+	if !errors.Is(err, ErrInvalidEnvironment) {
+		t.Fatalf("New() error = %v, want %v", err, ErrInvalidEnvironment)
+	}
+	for _, want := range []string{`(APP_ENV) got="prod"`, "development", "staging", "production"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
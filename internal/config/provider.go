@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type (
+	// Provider represents an external source of configuration values, such as a
+	// remote configuration service, keyed by the Env* constants.
+	//
+	// Providers are registered with [WithProviders] and layered below the
+	// configuration file, above the defaults.
+	Provider interface {
+		Load(ctx context.Context) (map[string]string, error)
+	}
+
+	// HTTPProvider is a [Provider] fetching a JSON object of configuration values
+	// with a GET request to URL.
+	HTTPProvider struct {
+		// URL specifies the address of the JSON object.
+		URL string
+
+		// Token specifies the optional bearer token sent in the Authorization header.
+		Token string
+
+		// Timeout specifies the time limit of the request. Zero means no limit
+		// other than the one of the context.
+		Timeout time.Duration
+
+		// Client specifies the HTTP client sending the request. Nil means
+		// [http.DefaultClient].
+		Client *http.Client
+	}
+
+	// StaticProvider is a [Provider] returning a fixed set of configuration values.
+	StaticProvider map[string]string
+
+	providerValues struct {
+		name string
+		vals map[string]string
+	}
+)
+
+// Load fetches and decodes the configuration values. Non-2xx responses,
+// non-object documents and non-scalar values are errors.
+func (p *HTTPProvider) Load(ctx context.Context) (map[string]string, error) {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %q", resp.Status)
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	var (
+		vals = make(map[string]string, len(doc))
+		errs []error
+	)
+	for _, k := range slices.Sorted(maps.Keys(doc)) {
+		switch v := doc[k].(type) {
+		case nil:
+		case string, json.Number, bool:
+			vals[k] = fmt.Sprint(v)
+		default:
+			errs = append(errs, fmt.Errorf("expected a scalar value for %q", k))
+		}
+	}
+	return vals, errors.Join(errs...)
+}
+
+// String returns the URL of p, identifying it in errors.
+func (p *HTTPProvider) String() string {
+	return p.URL
+}
+
+// Load returns a copy of the values of p.
+func (p StaticProvider) Load(context.Context) (map[string]string, error) {
+	return maps.Clone(p), nil
+}
+
+// String returns "static", identifying p in errors.
+func (p StaticProvider) String() string {
+	return "static"
+}
+
+// loadProviders loads the values of every provider in turn, stopping when the
+// context is done even if a provider ignores it. Failed providers are reported
+// as errors and keys naming no known variable as warnings.
+func (l *loader) loadProviders() {
+	for _, p := range l.providers {
+		name := providerName(p)
+		vals, err := loadProvider(l.ctx, p)
+		if l.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			l.addError(&ParseError{Var: name, Err: err, kind: ErrProvider})
+		}
+		for _, k := range slices.Sorted(maps.Keys(vals)) {
+			if !slices.Contains(envKeys, k) {
+				l.addWarning(&ValidationError{Var: k + " from " + name, Reason: "unknown variable" + suggest(k, envKeys), kind: ErrUnknownVariable})
+				delete(vals, k)
+			}
+		}
+		l.providerVals = append(l.providerVals, providerValues{name, vals})
+	}
+}
+
+// loadProvider calls p.Load in its own goroutine, returning ctx.Err() as soon as
+// ctx is done.
+func loadProvider(ctx context.Context, p Provider) (map[string]string, error) {
+	type result struct {
+		vals map[string]string
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		vals, err := p.Load(ctx)
+		ch <- result{vals, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.vals, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// providerName returns the name identifying p in errors: its String method when
+// it has one, its type otherwise.
+func providerName(p Provider) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
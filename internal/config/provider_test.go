@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, "got Authorization "+got, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"LOG_LEVEL": "debug", "SERVER_READ_TIMEOUT": 5, "LOG_FORMAT": null}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(WithLookuper(mapLookup(nil)), WithProviders(&HTTPProvider{URL: srv.URL, Token: "secret", Timeout: 5 * time.Second}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.LogLevel() != LogLevelDebug || c.ServerReadTimeout() != 5*time.Second {
+		t.Errorf("got %v and %v, want %v and 5s", c.LogLevel(), c.ServerReadTimeout(), LogLevelDebug)
+	}
+	if c.LogFormat() != DefaultLogFormat {
+		t.Errorf("LogFormat() = %v, want the default %v for a null value", c.LogFormat(), DefaultLogFormat)
+	}
+	if c.Source(EnvLogLevel) != SourceProvider {
+		t.Errorf("Source(%s) = %v, want %v", EnvLogLevel, c.Source(EnvLogLevel), SourceProvider)
+	}
+
+	_, err = New(WithLookuper(mapLookup(nil)), WithProviders(&HTTPProvider{URL: srv.URL}))
+	if !errors.Is(err, ErrProvider) || !strings.Contains(err.Error(), "("+srv.URL+")") || !strings.Contains(err.Error(), "401") {
+		t.Errorf("New() without the token error = %v, want a %v naming the provider and the status", err, ErrProvider)
+	}
+}
+
+func TestHTTPProviderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"non-2xx", http.StatusServiceUnavailable, `{}`, `unexpected status "503 Service Unavailable"`},
+		{"not an object", http.StatusOK, `["debug"]`, "cannot unmarshal array"},
+		{"non-scalar value", http.StatusOK, `{"LOG_LEVEL": ["debug"]}`, `expected a scalar value for "LOG_LEVEL"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			_, err := New(WithLookuper(mapLookup(nil)), WithProviders(&HTTPProvider{URL: srv.URL}))
+			if !errors.Is(err, ErrProvider) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want a %v containing %q", err, ErrProvider, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPProviderTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	_, err := New(WithLookuper(mapLookup(nil)), WithProviders(&HTTPProvider{URL: srv.URL, Timeout: 50 * time.Millisecond}))
+	if !errors.Is(err, ErrProvider) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("New() error = %v, want a %v wrapping %v", err, ErrProvider, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("New() took %v, want the provider timeout to apply", elapsed)
+	}
+}
+
+func TestProviderPrecedence(t *testing.T) {
+	high := StaticProvider{EnvLogLevel: "warn", EnvServerAddress: ":9001"}
+	low := StaticProvider{EnvLogLevel: "debug", EnvLogFormat: "json", EnvServerAddress: ":9002", EnvServerIdleTimeout: "90s"}
+	env := mapLookup(map[string]string{EnvServerAddress: ":9000"})
+	c, err := NewFromReader(strings.NewReader("server:\n  idle_timeout: 30s\n"), FormatYAML, WithLookuper(env), WithProviders(high, low))
+	if err != nil {
+		t.Fatalf("NewFromReader() error = %v", err)
+	}
+	got := []any{c.LogLevel(), c.LogFormat(), c.ServerAddress(), c.ServerIdleTimeout()}
+	want := []any{LogLevelWarn, LogFormatJSON, ":9000", 30 * time.Second}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// namelessProvider is a [Provider] without a String method.
+type namelessProvider map[string]string
+
+func (p namelessProvider) Load(context.Context) (map[string]string, error) {
+	return p, errors.New("partial outage")
+}
+
+func TestProviderUnknownKeys(t *testing.T) {
+	c, err := New(WithLookuper(mapLookup(nil)), WithProviders(StaticProvider{"LOG_LEVL": "debug", "FEATURE_X": "on", EnvLogFormat: "json"}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := []string{
+		"invalid configuration (FEATURE_X from static) unknown variable",
+		"invalid configuration (LOG_LEVL from static) unknown variable, did you mean LOG_LEVEL?",
+	}
+	if got := c.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+	if c.LogFormat() != LogFormatJSON {
+		t.Errorf("LogFormat() = %v, want %v", c.LogFormat(), LogFormatJSON)
+	}
+
+	_, err = New(WithLookuper(mapLookup(nil)), WithProviders(namelessProvider{}))
+	if want := "(config.namelessProvider): partial outage"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New() error = %v, want one containing %q", err, want)
+	}
+}
@@ -0,0 +1,344 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// VarType represents the type of the values of a configuration variable.
+	VarType string
+
+	// VarSpec describes a configuration variable for tooling such as documentation
+	// generators and admission controllers.
+	VarSpec struct {
+		// Name specifies the unprefixed variable name (one of the Env* constants).
+		Name string `json:"name"`
+
+		// Type specifies the type of the values of the variable.
+		Type VarType `json:"type"`
+
+		// Default specifies the value used when the variable is unset, if any.
+		Default string `json:"default,omitempty"`
+
+		// Description specifies what the variable configures.
+		Description string `json:"description"`
+
+		// Required reports whether the variable must be set in the strict mode
+		// (see [EnvConfigStrict]), unless marked with [Optional].
+		Required bool `json:"required"`
+
+		// EnumValues specifies the accepted values of [VarTypeEnum] variables.
+		EnumValues []string `json:"enumValues,omitempty"`
+	}
+)
+
+const (
+	// VarTypeString accepts any string.
+	VarTypeString VarType = "string"
+
+	// VarTypeEnum accepts one of the values listed by [VarSpec.EnumValues],
+	// case-insensitively.
+	VarTypeEnum VarType = "enum"
+
+	// VarTypeDuration accepts a [time.Duration] (e.g., "5s", "1m") or a bare number
+	// of seconds (e.g., "30", "0.5").
+	VarTypeDuration VarType = "duration"
+
+	// VarTypeBool accepts a boolean: 1, 0, true, false, yes or no, case-insensitively.
+	VarTypeBool VarType = "bool"
+
+	// VarTypeInt accepts a decimal integer.
+	VarTypeInt VarType = "int"
+
+	// VarTypeByteSize accepts a number of bytes, bare (e.g., "1024") or with a
+	// unit (e.g., "512KB", "10MiB"), as parsed by [ParseByteSize].
+	VarTypeByteSize VarType = "bytesize"
+)
+
+type (
+	// variable declares a configuration variable. The values of enum variables are
+	// restricted to values, while string variables merely canonicalize them.
+	// Duration variables warn about values above ceiling (defaultDurationCeiling
+	// when zero) and about zero unless allowZero is set.
+	// Variables backing [Config] fields set load, which reads the variable into
+	// the field, value, which renders the field, and buildDefault, which points
+	// to the Default*Str variable overriding fallback at build time. The values
+	// of sensitive variables are redacted wherever they are rendered.
+	variable struct {
+		envKey       string
+		description  string
+		kind         VarType
+		format       string
+		values       []string
+		invalid      error
+		fallback     string
+		ceiling      time.Duration
+		allowZero    bool
+		noPrefix     bool
+		sensitive    bool
+		buildDefault *string
+		load         func(c *Config, l *loader, v variable)
+		value        func(c *Config) string
+	}
+)
+
+// registry declares every configuration variable, in declaration order. It
+// drives the loading, the command-line flags, the configuration file keys, the
+// detection of unknown variables, [Spec] and [WriteEnvTemplate].
+var registry = []variable{
+	{
+		envKey:       EnvLogLevel,
+		description:  "severity or verbosity of log records",
+		kind:         VarTypeEnum,
+		values:       logLevels,
+		invalid:      ErrInvalidLogLevel,
+		fallback:     string(DefaultLogLevel),
+		buildDefault: &DefaultLogLevelStr,
+		load:         func(c *Config, l *loader, v variable) { c.logLevel = LogLevel(l.lookupEnum(v)) },
+		value:        func(c *Config) string { return string(c.logLevel) },
+	},
+	{
+		envKey:       EnvLogFormat,
+		description:  "encoding style of log records",
+		kind:         VarTypeEnum,
+		values:       logFormats,
+		invalid:      ErrInvalidLogFormat,
+		fallback:     string(DefaultLogFormat),
+		buildDefault: &DefaultLogFormatStr,
+		load:         func(c *Config, l *loader, v variable) { c.logFormat = LogFormat(l.lookupEnum(v)) },
+		value:        func(c *Config) string { return string(c.logFormat) },
+	},
+	{
+		envKey:       EnvLogOutput,
+		description:  "destination stream of log records",
+		kind:         VarTypeString,
+		format:       "stdout, stderr or a file path",
+		values:       logOutputs,
+		fallback:     string(DefaultLogOutput),
+		buildDefault: &DefaultLogOutputStr,
+		load:         func(c *Config, l *loader, v variable) { c.logOutput = LogOutput(l.lookupString(v, nil)) },
+		value:        func(c *Config) string { return string(c.logOutput) },
+	},
+	{
+		envKey:       EnvServerAddress,
+		description:  "server's address",
+		kind:         VarTypeString,
+		format:       "comma-separated <host>:port or unix://<path> (e.g., localhost:8080, :3000, unix:///run/mega.sock)",
+		fallback:     DefaultServerAddress,
+		buildDefault: &DefaultServerAddressStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverAddresses = l.lookupAddresses(v) },
+		value:        func(c *Config) string { return strings.Join(c.ServerAddresses(), ",") },
+	},
+	{
+		envKey:       EnvServerReadTimeout,
+		description:  "server's read timeout",
+		kind:         VarTypeDuration,
+		fallback:     DefaultServerReadTimeout.String(),
+		buildDefault: &DefaultServerReadTimeoutStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverReadTimeout = l.lookupDuration(v) },
+		value:        func(c *Config) string { return c.serverReadTimeout.String() },
+	},
+	{
+		envKey:       EnvServerReadHeaderTimeout,
+		description:  "server's read header timeout",
+		kind:         VarTypeDuration,
+		fallback:     DefaultServerReadHeaderTimeout.String(),
+		buildDefault: &DefaultServerReadHeaderTimeoutStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverReadHeaderTimeout = l.lookupDuration(v) },
+		value:        func(c *Config) string { return c.serverReadHeaderTimeout.String() },
+	},
+	{
+		envKey:       EnvServerWriteTimeout,
+		description:  "server's write timeout",
+		kind:         VarTypeDuration,
+		fallback:     DefaultServerWriteTimeout.String(),
+		buildDefault: &DefaultServerWriteTimeoutStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverWriteTimeout = l.lookupDuration(v) },
+		value:        func(c *Config) string { return c.serverWriteTimeout.String() },
+	},
+	{
+		envKey:       EnvServerIdleTimeout,
+		description:  "server's idle timeout",
+		kind:         VarTypeDuration,
+		fallback:     DefaultServerIdleTimeout.String(),
+		buildDefault: &DefaultServerIdleTimeoutStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverIdleTimeout = l.lookupDuration(v) },
+		value:        func(c *Config) string { return c.serverIdleTimeout.String() },
+	},
+	{
+		envKey:       EnvServerShutdownTimeout,
+		description:  "server's shutdown timeout",
+		kind:         VarTypeDuration,
+		fallback:     DefaultServerShutdownTimeout.String(),
+		buildDefault: &DefaultServerShutdownTimeoutStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverShutdownTimeout = l.lookupDuration(v) },
+		value:        func(c *Config) string { return c.serverShutdownTimeout.String() },
+	},
+	{
+		envKey:       EnvServerUnixSocketMode,
+		description:  "file mode of the server's Unix domain socket",
+		kind:         VarTypeString,
+		format:       "octal file mode (e.g., 0660, 0600)",
+		fallback:     fmt.Sprintf("%04o", DefaultServerUnixSocketMode),
+		buildDefault: &DefaultServerUnixSocketModeStr,
+		load:         func(c *Config, l *loader, v variable) { c.serverUnixSocketMode = l.lookupFileMode(v) },
+		value:        func(c *Config) string { return fmt.Sprintf("%04o", c.serverUnixSocketMode) },
+	},
+	{
+		envKey:       EnvServerMaxHeaderBytes,
+		description:  "maximum size of the request headers read by the server",
+		kind:         VarTypeByteSize,
+		format:       `byte size (e.g., "1MiB", "512KB", "65536")`,
+		fallback:     strconv.Itoa(DefaultServerMaxHeaderBytes),
+		buildDefault: &DefaultServerMaxHeaderBytesStr,
+		load: func(c *Config, l *loader, v variable) {
+			c.serverMaxHeaderBytes = int(l.lookupByteSize(v, 1, math.MaxInt32))
+		},
+		value: func(c *Config) string { return strconv.Itoa(c.serverMaxHeaderBytes) },
+	},
+	{
+		envKey:      EnvAppEnv,
+		description: "deployment environment, selecting the set of defaults in effect",
+		kind:        VarTypeEnum,
+		values:      environments,
+		invalid:     ErrInvalidEnvironment,
+		fallback:    string(DefaultEnvironment),
+		value:       func(c *Config) string { return string(c.environment) },
+	},
+	{
+		envKey:      EnvConfigEnvFile,
+		description: "path of an env file whose entries apply to variables unset in the environment",
+		kind:        VarTypeString,
+		format:      "file path",
+		fallback:    DefaultConfigEnvFile,
+	},
+	{
+		envKey:      EnvConfigFile,
+		description: "path of an optional YAML or JSON configuration file",
+		kind:        VarTypeString,
+		format:      "file path",
+	},
+	{
+		envKey:      EnvPrefix,
+		description: "prefix prepended to every other variable name",
+		kind:        VarTypeString,
+		format:      `variable name prefix (e.g., "MYAPP")`,
+		noPrefix:    true,
+	},
+	{
+		envKey:      EnvConfigStrict,
+		description: "whether every variable without a value is an error",
+		kind:        VarTypeBool,
+		fallback:    strconv.FormatBool(DefaultConfigStrict),
+	},
+	{
+		envKey:      EnvConfigDir,
+		description: "path of an optional directory in which each file holds the value of the variable it is named after",
+		kind:        VarTypeString,
+		format:      "directory path",
+	},
+	{
+		envKey:      EnvConfigWatchInterval,
+		description: "configuration file polling interval, zero disabling it",
+		kind:        VarTypeDuration,
+		allowZero:   true,
+		fallback:    DefaultConfigWatchInterval.String(),
+	},
+}
+
+// envKeys lists the variables read under the prefix, in declaration order.
+var envKeys = func() []string {
+	var keys []string
+	for _, v := range registry {
+		if !v.noPrefix {
+			keys = append(keys, v.envKey)
+		}
+	}
+	return keys
+}()
+
+// Spec returns the description of every configuration variable, in declaration
+// order. Defaults reflect the build-time defaults, if any.
+func Spec() []VarSpec {
+	specs := make([]VarSpec, len(registry))
+	for i, v := range registry {
+		specs[i] = VarSpec{
+			Name:        v.envKey,
+			Type:        v.kind,
+			Default:     defaultValue(v.envKey, v.fallback),
+			Description: v.description,
+			Required:    v.load != nil,
+		}
+		if v.kind == VarTypeEnum {
+			specs[i].EnumValues = slices.Clone(v.values)
+		}
+	}
+	return specs
+}
+
+// WriteEnvTemplate writes to w an env file template documenting every
+// configuration variable, in declaration order, with its description, accepted
+// values or format and default, followed by a commented-out assignment of the
+// default. The output is deterministic, so it can be committed (e.g., as
+// ".env.example") and diffed.
+func WriteEnvTemplate(w io.Writer) error {
+	var b bytes.Buffer
+	for i, v := range registry {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fallback := defaultValue(v.envKey, v.fallback)
+		fmt.Fprintf(&b, "# %s: %s.\n", v.envKey, v.description)
+		switch v.kind {
+		case VarTypeEnum:
+			fmt.Fprintf(&b, "# Values: %s\n", strings.Join(v.values, ", "))
+		case VarTypeBool:
+			fmt.Fprintf(&b, "# Values: true, false\n")
+		case VarTypeDuration:
+			fmt.Fprintf(&b, "# Format: %s\n", durationFormat)
+		default:
+			fmt.Fprintf(&b, "# Format: %s\n", v.format)
+		}
+		if fallback != "" {
+			fmt.Fprintf(&b, "# Default: %s\n", fallback)
+		}
+		fmt.Fprintf(&b, "# %s=%s\n", v.envKey, fallback)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+const durationFormat = `duration (e.g., "5s", "1m") or number of seconds (e.g., "30")`
+
+// variableFor returns the declaration of envKey, which must be one of the Env*
+// constants.
+func variableFor(envKey string) variable {
+	idx := slices.IndexFunc(registry, func(v variable) bool {
+		return v.envKey == envKey
+	})
+	return registry[idx]
+}
+
+// defaultDurationCeiling is the ceiling of the duration variables declaring none.
+const defaultDurationCeiling = 24 * time.Hour
+
+// durationCeiling returns the value above which the duration variable v warns.
+func (v variable) durationCeiling() time.Duration {
+	if v.ceiling > 0 {
+		return v.ceiling
+	}
+	return defaultDurationCeiling
+}
+
+// fallbackDuration returns the default of the duration variable v.
+func (v variable) fallbackDuration() time.Duration {
+	d, _ := time.ParseDuration(v.fallback)
+	return d
+}
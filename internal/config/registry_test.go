@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// envConstants returns the values of the Env* constants declared in config.go,
+// except the [Environment] values.
+func envConstants(t *testing.T) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "config.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, id := range vs.Names {
+				if !strings.HasPrefix(id.Name, "Env") || strings.HasPrefix(id.Name, "Environment") || i >= len(vs.Values) {
+					continue
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				name, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		t.Fatal("no Env* constant found in config.go")
+	}
+	return names
+}
+
+func TestWriteEnvTemplate(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteEnvTemplate(&b); err != nil {
+		t.Fatalf("WriteEnvTemplate() error = %v", err)
+	}
+	golden := filepath.Join("testdata", "env.example")
+	if *update {
+		if err := os.WriteFile(golden, b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != string(want) {
+		t.Errorf("WriteEnvTemplate() =\n%s\nwant\n%s", got, want)
+	}
+
+	var again bytes.Buffer
+	if err := WriteEnvTemplate(&again); err != nil || again.String() != b.String() {
+		t.Error("WriteEnvTemplate() output is not deterministic")
+	}
+}
+
+func TestWriteEnvTemplateCoversEveryVariable(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteEnvTemplate(&b); err != nil {
+		t.Fatalf("WriteEnvTemplate() error = %v", err)
+	}
+	for _, name := range envConstants(t) {
+		if n := strings.Count(b.String(), "\n# "+name+"="); n != 1 {
+			t.Errorf("%s is assigned %d times, want exactly once", name, n)
+		}
+	}
+}
+
+func TestSpec(t *testing.T) {
+	specs := Spec()
+	var names []string
+	for _, s := range specs {
+		names = append(names, s.Name)
+		if s.Description == "" {
+			t.Errorf("%s has no description", s.Name)
+		}
+		if (s.Type == VarTypeEnum) != (len(s.EnumValues) > 0) {
+			t.Errorf("%s of type %v has enum values %q", s.Name, s.Type, s.EnumValues)
+		}
+	}
+	constants := envConstants(t)
+	slices.Sort(names)
+	slices.Sort(constants)
+	if !slices.Equal(names, constants) {
+		t.Errorf("Spec() names = %q, want the Env* constants %q", names, constants)
+	}
+
+	level := specs[slices.IndexFunc(specs, func(s VarSpec) bool { return s.Name == EnvLogLevel })]
+	want := VarSpec{
+		Name:        EnvLogLevel,
+		Type:        VarTypeEnum,
+		Default:     string(DefaultLogLevel),
+		Description: level.Description,
+		Required:    true,
+		EnumValues:  []string{"debug", "info", "warn", "error"},
+	}
+	if !reflect.DeepEqual(level, want) {
+		t.Errorf("Spec() for %s = %+v, want %+v", EnvLogLevel, level, want)
+	}
+
+	b, err := json.Marshal(specs)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded []VarSpec
+	if err := json.Unmarshal(b, &decoded); err != nil || !reflect.DeepEqual(decoded, specs) {
+		t.Errorf("Spec() does not round trip through JSON: %s", b)
+	}
+}
+
+func TestSpecBuildDefault(t *testing.T) {
+	setBuildDefault(t, &DefaultServerIdleTimeoutStr, "90s")
+	for _, s := range Spec() {
+		if s.Name == EnvServerIdleTimeout && s.Default != "90s" {
+			t.Errorf("Spec() default for %s = %q, want the build-time default 90s", s.Name, s.Default)
+		}
+	}
+}
+
+// TestRegistryCoversAccessors checks that every accessor of a configuration
+// value on [Config] is backed by a registered variable, named after it.
+func TestRegistryCoversAccessors(t *testing.T) {
+	derived := []string{
+		"ServerAddresses", "ServerEndpoints", "ServerNetwork", "ServerHost", "ServerPort",
+		"Diff", "MarshalJSON", "Source", "Sources", "String", "Warnings",
+	}
+	typ := reflect.TypeFor[*Config]()
+	for i := range typ.NumMethod() {
+		name := typ.Method(i).Name
+		if slices.Contains(derived, name) {
+			continue
+		}
+		envKey := EnvAppEnv
+		if name != "Environment" {
+			envKey = snakeCase(name)
+		}
+		idx := slices.IndexFunc(registry, func(v variable) bool { return v.envKey == envKey })
+		if idx < 0 || registry[idx].value == nil {
+			t.Errorf("Config.%s is not backed by a registered variable %s", name, envKey)
+		}
+	}
+}
+
+// snakeCase converts a Go identifier such as "ServerReadTimeout" to the
+// matching variable name, "SERVER_READ_TIMEOUT".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,11 @@
+//go:build !js
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals lists the signals [Manager.ReloadOnSignal] listens to by default.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,9 @@
+package config
+
+import (
+	"os"
+)
+
+// reloadSignals lists the signals [Manager.ReloadOnSignal] listens to by default.
+// There are none on js, which has no SIGHUP.
+var reloadSignals []os.Signal
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+// ignoredVars lists the variables in the LOG_* and SERVER_* namespaces that are
+// set by common platforms (e.g., CGI) and are not configuration variables.
+var ignoredVars = []string{
+	"SERVER_ADDR",
+	"SERVER_NAME",
+	"SERVER_PORT",
+	"SERVER_PROTOCOL",
+	"SERVER_SOFTWARE",
+}
+
+// unknownPrefixes lists the namespaces owned by the configuration, in which
+// unrecognized variables are reported.
+var unknownPrefixes = []string{
+	"LOG_",
+	"SERVER_",
+}
+
+// checkUnknown records a warning for every variable of the environment and the
+// env file that belongs to a namespace owned by the configuration but is not a
+// known configuration variable.
+func (l *loader) checkUnknown() {
+	known := append(EnvNames(l.prefix), envKeys...)
+	for _, name := range known {
+		known = append(known, name+envFileSuffix)
+	}
+	var names []string
+	if l.environ != nil {
+		for _, kv := range l.environ() {
+			name, _, _ := strings.Cut(kv, "=")
+			names = append(names, name)
+		}
+	}
+	for name := range l.envFile {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if !l.ownsVar(name) || slices.Contains(known, name) || slices.Contains(l.ignored, name) {
+			continue
+		}
+		l.addWarning(&ValidationError{Var: name, Reason: "unknown variable" + suggest(name, known), kind: ErrUnknownVariable})
+	}
+}
+
+func (l *loader) ownsVar(name string) bool {
+	for _, prefix := range unknownPrefixes {
+		if strings.HasPrefix(name, prefix) || strings.HasPrefix(name, EnvName(l.prefix, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns a "did you mean" hint naming the candidate closest to name, or
+// an empty string when no candidate is close enough to be a likely typo.
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", len(name)/3+1
+	for _, c := range candidates {
+		if d := levenshtein(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return ", did you mean " + best + "?"
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"SERVER_WRITE_TIMOUT", "SERVER_WRITE_TIMEOUT"},
+		{"SERVER_READTIMEOUT", "SERVER_READ_TIMEOUT"},
+		{"LOG_LEVL", "LOG_LEVEL"},
+		{"LOG_FROMAT", "LOG_FORMAT"},
+		{"SERVER_ADRESS", "SERVER_ADDRESS"},
+		{"SERVER_IDLE_TIMEOUTS", "SERVER_IDLE_TIMEOUT"},
+		{"LOG_COLOR", ""},
+		{"SERVER_TLS_CERT", ""},
+	}
+	for _, tt := range tests {
+		got := suggest(tt.name, envKeys)
+		if tt.want == "" {
+			if got != "" {
+				t.Errorf("suggest(%q) = %q, want no suggestion", tt.name, got)
+			}
+			continue
+		}
+		if want := ", did you mean " + tt.want + "?"; got != want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"TIMOUT", "TIMEOUT", 1},
+		{"same", "same", 0},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownVariables(t *testing.T) {
+	setenv(t, map[string]string{
+		"SERVER_WRITE_TIMOUT": "30s",
+		"LOG_COLOR":           "always",
+		"SERVER_SOFTWARE":     "nginx",
+		"SERVER_NAME":         "example.com",
+		"DATABASE_URL":        "postgres://",
+		"SERVER_TEAM":         "infra",
+	})
+	writeFile(t, ".env", "LOG_LEVL=debug\n")
+
+	c, err := New(IgnoreUnknown("SERVER_TEAM"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := []string{
+		"invalid configuration (LOG_COLOR) unknown variable",
+		"invalid configuration (LOG_LEVL) unknown variable, did you mean LOG_LEVEL?",
+		"invalid configuration (SERVER_WRITE_TIMOUT) unknown variable, did you mean SERVER_WRITE_TIMEOUT?",
+	}
+	if got := c.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+
+	_, err = New(IgnoreUnknown("SERVER_TEAM"), Strict(), Optional(envKeys...))
+	if !errors.Is(err, ErrUnknownVariable) {
+		t.Fatalf("New() in strict mode error = %v, want %v", err, ErrUnknownVariable)
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error %q does not contain %q", err, w)
+		}
+	}
+}
+
+func TestUnknownVariablesPrefixed(t *testing.T) {
+	setenv(t, map[string]string{
+		EnvPrefix:              "MYAPP",
+		"MYAPP_LOG_LEVEL":      "debug",
+		"MYAPP_LOG_LEVEL_FILE": "",
+		"MYAPP_SERVER_ADRESS":  ":80",
+	})
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := []string{"invalid configuration (MYAPP_SERVER_ADRESS) unknown variable, did you mean MYAPP_SERVER_ADDRESS?"}
+	if got := c.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+}
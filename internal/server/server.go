@@ -0,0 +1,207 @@
+// Package server runs the application's HTTP server.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"mega/internal/config"
+)
+
+type (
+	// Server represents the application's HTTP server, configured by a
+	// [config.Config]. It listens on every configured server's address, serving
+	// the same handler on all of them.
+	//
+	// Binding the addresses ([Server.Listen]) is separate from serving requests
+	// ([Server.Serve]), so that the actual addresses are observable through
+	// [Server.Addrs] before traffic starts, e.g., when listening on port 0.
+	Server struct {
+		cfg       *config.Config
+		endpoints []config.ServerEndpoint
+		srv       *http.Server
+		mu        sync.Mutex
+		listeners []net.Listener
+		err       error
+		ready     chan struct{}
+	}
+)
+
+// New creates and returns a new [Server] instance serving handler on the
+// configured server's addresses with the configured timeouts.
+func New(cfg *config.Config, handler http.Handler) *Server {
+	return &Server{
+		cfg:       cfg,
+		endpoints: cfg.ServerEndpoints(),
+		srv: &http.Server{
+			Handler:           handler,
+			ReadTimeout:       cfg.ServerReadTimeout(),
+			ReadHeaderTimeout: cfg.ServerReadHeaderTimeout(),
+			WriteTimeout:      cfg.ServerWriteTimeout(),
+			IdleTimeout:       cfg.ServerIdleTimeout(),
+			MaxHeaderBytes:    cfg.ServerMaxHeaderBytes(),
+		},
+		ready: make(chan struct{}),
+	}
+}
+
+// Listen binds every configured server's address, closing the channel returned
+// by [Server.Ready] once done. If any address cannot be bound, the ones already
+// bound are released and the error is also reported by [Server.Err]. Calling it
+// again returns the outcome of the first call.
+//
+// For Unix domain sockets, a stale socket file left by a previous process is
+// removed first, and the socket is given the configured file mode.
+func (s *Server) Listen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.ready:
+		return s.err
+	default:
+	}
+	defer close(s.ready)
+	listeners := make([]net.Listener, 0, len(s.endpoints))
+	for _, e := range s.endpoints {
+		ln, err := s.listen(e)
+		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			s.err = err
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+	s.listeners = listeners
+	return nil
+}
+
+func (s *Server) listen(e config.ServerEndpoint) (net.Listener, error) {
+	if e.Network == "unix" {
+		if err := removeStaleSocket(e.Address); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen(e.Network, e.Address)
+	if err != nil {
+		return nil, err
+	}
+	if e.Network == "unix" {
+		if err := os.Chmod(e.Address, s.cfg.ServerUnixSocketMode()); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// Serve serves requests on every bound address until [Server.Shutdown] is
+// called, binding the addresses first unless [Server.Listen] already did. It
+// returns nil after a shutdown. If serving fails on any address, the server is
+// closed and the error is returned.
+func (s *Server) Serve() error {
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	listeners := s.listeners
+	s.mu.Unlock()
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, ln := range listeners {
+		wg.Go(func() {
+			if err := s.srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				s.srv.Close()
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Addr returns the first address the server is bound to, or nil before
+// [Server.Listen] succeeds. See [Server.Addrs].
+func (s *Server) Addr() net.Addr {
+	if addrs := s.Addrs(); len(addrs) > 0 {
+		return addrs[0]
+	}
+	return nil
+}
+
+// Addrs returns the addresses the server is bound to, in configuration order and
+// with the actual port when listening on port 0, or nil before [Server.Listen]
+// succeeds.
+func (s *Server) Addrs() []net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var addrs []net.Addr
+	for _, ln := range s.listeners {
+		addrs = append(addrs, ln.Addr())
+	}
+	return addrs
+}
+
+// Ready returns a channel closed once [Server.Listen] is done, after which
+// [Server.Err] reports whether the addresses were bound and [Server.Addrs] is
+// available.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Err returns the error that made [Server.Listen] fail, or nil while listening
+// is pending or after it succeeded.
+func (s *Server) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Shutdown gracefully stops the server on every address, waiting for the active
+// requests to complete for at most the configured shutdown timeout, when
+// positive, or until ctx is done. The bound addresses are released, including
+// when [Server.Serve] was never called. Closing a Unix domain socket removes its
+// socket file, so only the files created by the server are removed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if timeout := s.cfg.ServerShutdownTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := s.srv.Shutdown(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ln := range s.listeners {
+		if cErr := ln.Close(); cErr != nil && !errors.Is(cErr, net.ErrClosed) {
+			err = errors.Join(err, cErr)
+		}
+	}
+	return err
+}
+
+// removeStaleSocket removes the Unix domain socket file at path unless a server
+// still accepts connections on it. Files other than sockets are left untouched,
+// making [net.Listen] fail.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Type() != fs.ModeSocket {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,474 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mega/internal/config"
+)
+
+// newServer returns a [Server] configured with env, answering "ok" to every
+// request.
+func newServer(t *testing.T, env map[string]string) *Server {
+	t.Helper()
+	cfg, err := config.NewFromMap(env)
+	if err != nil {
+		t.Fatalf("config.NewFromMap() error = %v", err)
+	}
+	return New(cfg, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+}
+
+// serve runs s.Serve in the background, returning a channel receiving its
+// result.
+func serve(s *Server) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve()
+	}()
+	return done
+}
+
+// waitReady waits until s is done listening.
+func waitReady(t *testing.T, s *Server) {
+	t.Helper()
+	select {
+	case <-s.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ready() was not closed")
+	}
+}
+
+// get requests "/" through client and checks the answer.
+func get(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if string(b) != "ok" {
+		t.Errorf("GET %s = %q, want ok", url, b)
+	}
+}
+
+// shutdown shuts s down and checks that serving stopped cleanly.
+func shutdown(t *testing.T, s *Server, done <-chan error) {
+	t.Helper()
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after Shutdown()")
+	}
+}
+
+func TestServerEphemeralPort(t *testing.T) {
+	s := newServer(t, map[string]string{config.EnvServerAddress: "127.0.0.1:0"})
+	if s.Addr() != nil {
+		t.Errorf("Addr() before Listen() = %v, want nil", s.Addr())
+	}
+	done := serve(s)
+	waitReady(t, s)
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	addr, ok := s.Addr().(*net.TCPAddr)
+	if !ok || addr.Port == 0 {
+		t.Fatalf("Addr() = %v, want the bound ephemeral port", s.Addr())
+	}
+	get(t, http.DefaultClient, "http://"+addr.String())
+	shutdown(t, s, done)
+
+	if conn, err := net.Dial("tcp", addr.String()); err == nil {
+		conn.Close()
+		t.Error("the port still accepts connections after Shutdown()")
+	}
+}
+
+func TestServerListenThenServe(t *testing.T) {
+	s := newServer(t, map[string]string{config.EnvServerAddress: "127.0.0.1:0"})
+	if err := s.Listen(); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	if err := s.Listen(); err != nil {
+		t.Errorf("second Listen() error = %v", err)
+	}
+	select {
+	case <-s.Ready():
+	default:
+		t.Fatal("Ready() is not closed after Listen()")
+	}
+	addr := s.Addr().String()
+	done := serve(s)
+	get(t, http.DefaultClient, "http://"+addr)
+	shutdown(t, s, done)
+}
+
+func TestServerListenFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	s := newServer(t, map[string]string{config.EnvServerAddress: taken.Addr().String()})
+	done := serve(s)
+	waitReady(t, s)
+	if s.Err() == nil {
+		t.Fatal("Err() = nil, want the bind error")
+	}
+	if s.Addrs() != nil {
+		t.Errorf("Addrs() = %v, want nil", s.Addrs())
+	}
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != s.Err().Error() {
+			t.Errorf("Serve() error = %v, want %v", err, s.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return")
+	}
+	if err := s.Listen(); err == nil {
+		t.Error("Listen() after a failure error = nil, want the first error")
+	}
+}
+
+func TestServerShutdownWithoutServe(t *testing.T) {
+	s := newServer(t, map[string]string{config.EnvServerAddress: "127.0.0.1:0"})
+	if err := s.Listen(); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := s.Addr().String()
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("the port was not released: %v", err)
+	}
+	ln.Close()
+}
+
+// unixClient returns an HTTP client connecting to the Unix domain socket at
+// path whatever the URL.
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	s := newServer(t, map[string]string{
+		config.EnvServerAddress:        "unix://" + path,
+		config.EnvServerUnixSocketMode: "0600",
+	})
+	done := serve(s)
+	waitReady(t, s)
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want 0600", info.Mode().Perm())
+	}
+	if s.Addr().Network() != "unix" {
+		t.Errorf("Addr().Network() = %q, want unix", s.Addr().Network())
+	}
+	get(t, unixClient(path), "http://unix/")
+	shutdown(t, s, done)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file left after Shutdown(): %v", err)
+	}
+}
+
+func TestServerUnixSocketRelative(t *testing.T) {
+	t.Chdir(t.TempDir())
+	s := newServer(t, map[string]string{config.EnvServerAddress: "unix://./app.sock"})
+	if err := s.Listen(); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	if _, err := os.Stat("app.sock"); err != nil {
+		t.Errorf("socket file not created: %v", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+}
+
+func TestServerUnixSocketStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.SetUnlinkOnClose(false)
+	ln.Close()
+
+	s := newServer(t, map[string]string{config.EnvServerAddress: "unix://" + path})
+	done := serve(s)
+	waitReady(t, s)
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v, want the stale socket replaced", err)
+	}
+	get(t, unixClient(path), "http://unix/")
+	shutdown(t, s, done)
+}
+
+func TestServerUnixSocketInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	other, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	s := newServer(t, map[string]string{config.EnvServerAddress: "unix://" + path})
+	if err := s.Listen(); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Fatalf("Listen() error = %v, want the socket in use", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("the socket of the other server was removed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestServerUnixSocketRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(t, map[string]string{config.EnvServerAddress: "unix://" + path})
+	if err := s.Listen(); err == nil {
+		t.Fatal("Listen() error = nil, want an error")
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "data" {
+		t.Errorf("the regular file was modified: %q, %v", b, err)
+	}
+}
+
+func TestServerMultipleAddresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	addrs := "127.0.0.1:0,unix://" + path
+	if ln, err := net.Listen("tcp", "[::1]:0"); err == nil {
+		ln.Close()
+		addrs += ",[::1]:0"
+	}
+	s := newServer(t, map[string]string{config.EnvServerAddress: addrs})
+	done := serve(s)
+	waitReady(t, s)
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	bound := s.Addrs()
+	if len(bound) != len(strings.Split(addrs, ",")) {
+		t.Fatalf("Addrs() = %v, want one per configured address", bound)
+	}
+	if s.Addr() != bound[0] {
+		t.Errorf("Addr() = %v, want the first of %v", s.Addr(), bound)
+	}
+	for _, a := range bound {
+		if a.Network() == "unix" {
+			get(t, unixClient(path), "http://unix/")
+			continue
+		}
+		get(t, http.DefaultClient, "http://"+a.String())
+	}
+	shutdown(t, s, done)
+	for _, a := range bound {
+		if conn, err := net.Dial(a.Network(), a.String()); err == nil {
+			conn.Close()
+			t.Errorf("%v still accepts connections after Shutdown()", a)
+		}
+	}
+}
+
+func TestServerMultipleAddressesBindFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freeAddr := free.Addr().String()
+	free.Close()
+
+	s := newServer(t, map[string]string{config.EnvServerAddress: freeAddr + "," + taken.Addr().String()})
+	if err := s.Listen(); err == nil {
+		t.Fatal("Listen() error = nil, want an error")
+	}
+	ln, err := net.Listen("tcp", freeAddr)
+	if err != nil {
+		t.Fatalf("the address bound before the failure was not released: %v", err)
+	}
+	ln.Close()
+}
+
+func TestServerMultipleAddressesDrain(t *testing.T) {
+	cfg, err := config.NewFromMap(map[string]string{config.EnvServerAddress: "127.0.0.1:0,127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Warnings()) != 1 {
+		t.Errorf("Warnings() = %q, want the duplicate address reported", cfg.Warnings())
+	}
+	path := filepath.Join(t.TempDir(), "app.sock")
+	cfg, err = config.NewFromMap(map[string]string{config.EnvServerAddress: "127.0.0.1:0,unix://" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	s := New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		io.WriteString(w, "ok")
+	}))
+	done := serve(s)
+	waitReady(t, s)
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	slow := make(chan error, 1)
+	go func() {
+		resp, err := unixClient(path).Get("http://unix/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		slow <- err
+	}()
+	<-started
+	shut := make(chan error, 1)
+	go func() {
+		shut <- s.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-shut:
+		t.Fatalf("Shutdown() = %v before the in-flight request completed", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if conn, err := net.Dial("tcp", s.Addrs()[0].String()); err == nil {
+		conn.Close()
+		t.Error("the first address still accepts connections while draining")
+	}
+	close(release)
+	if err := <-slow; err != nil {
+		t.Errorf("in-flight request error = %v", err)
+	}
+	if err := <-shut; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
+
+func TestServerShutdownWithoutTimeout(t *testing.T) {
+	cfg, err := config.NewFromMap(map[string]string{
+		config.EnvServerAddress:         "127.0.0.1:0",
+		config.EnvServerShutdownTimeout: "0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	s := New(cfg, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "ok")
+	}))
+	done := serve(s)
+	waitReady(t, s)
+
+	slow := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + s.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		slow <- err
+	}()
+	<-started
+	shut := make(chan error, 1)
+	go func() {
+		shut <- s.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-shut:
+		t.Fatalf("Shutdown() = %v before the in-flight request completed", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if err := <-slow; err != nil {
+		t.Errorf("in-flight request error = %v", err)
+	}
+	if err := <-shut; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
+
+func TestServerMaxHeaderBytes(t *testing.T) {
+	s := newServer(t, map[string]string{
+		config.EnvServerAddress:        "127.0.0.1:0",
+		config.EnvServerMaxHeaderBytes: "4KiB",
+	})
+	if s.srv.MaxHeaderBytes != 4<<10 {
+		t.Errorf("MaxHeaderBytes = %d, want %d", s.srv.MaxHeaderBytes, 4<<10)
+	}
+	done := serve(s)
+	waitReady(t, s)
+	req, err := http.NewRequest(http.MethodGet, "http://"+s.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Large", strings.Repeat("x", 16<<10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("GET with large headers status = %d, want %d", resp.StatusCode, http.StatusRequestHeaderFieldsTooLarge)
+	}
+	get(t, http.DefaultClient, "http://"+s.Addr().String())
+	shutdown(t, s, done)
+}